@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"github.com/cmcpasserby/ucb/cmd/cloudbuild/settings"
+	"sort"
+)
+
+type aliasRow struct {
+	Name          string `json:"name"`
+	ProjectId     string `json:"projectId"`
+	BuildTargetId string `json:"buildTargetId"`
+}
+
+func aliasRows(aliases map[string]settings.Alias) []aliasRow {
+	rows := make([]aliasRow, 0, len(aliases))
+	for name, alias := range aliases {
+		rows = append(rows, aliasRow{"@" + name, alias.ProjectId, alias.BuildTargetId})
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
@@ -0,0 +1,410 @@
+package cli
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/cmcpasserby/ucb/cmd/cloudbuild/settings"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild/responses"
+	"golang.org/x/crypto/ssh/terminal"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultPollInterval = "30s"
+
+// buildStatusFilters maps the --status values to the api's build statuses
+var buildStatusFilters = map[string]responses.BuildStatus{
+	"queued":   responses.BuildStatusQueued,
+	"building": responses.BuildStatusStarted,
+	"success":  responses.BuildStatusSuccess,
+	"failure":  responses.BuildStatusFailure,
+	"canceled": responses.BuildStatusCanceled,
+}
+
+func listBuildsOptions(flags map[string]string) (cloudbuild.ListBuildsOptions, error) {
+	var opts cloudbuild.ListBuildsOptions
+
+	if val := flags["status"]; val != "" {
+		status, ok := buildStatusFilters[val]
+		if !ok {
+			return opts, validationErrorf("--status: invalid build status %q", val)
+		}
+		opts.Status = status
+	}
+
+	if val := flags["limit"]; val != "" {
+		limit, err := strconv.Atoi(val)
+		if err != nil || limit < 0 {
+			return opts, validationErrorf("--limit: invalid limit %q", val)
+		}
+		opts.Limit = limit
+	}
+
+	if val := flags["since"]; val != "" {
+		since, err := parseSince(val)
+		if err != nil {
+			return opts, validationErrorf("--since: %v", err)
+		}
+		opts.Since = since
+	}
+
+	return opts, nil
+}
+
+type buildRow struct {
+	Number   int                   `json:"build"`
+	Status   responses.BuildStatus `json:"status"`
+	Finished time.Time             `json:"finished"`
+}
+
+func buildRows(builds []responses.Build) []buildRow {
+	rows := make([]buildRow, 0, len(builds))
+	for _, build := range builds {
+		rows = append(rows, buildRow{build.Number, build.Status, build.Finished})
+	}
+	return rows
+}
+
+// noBuilds is the status shown for a build target that has never been built
+const noBuilds = "no builds"
+
+type latestBuild struct {
+	BuildTargetId string    `json:"buildtargetid"`
+	Name          string    `json:"name"`
+	Number        int       `json:"build,omitempty"`
+	Status        string    `json:"status"`
+	Finished      time.Time `json:"finished"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// latestBuilds fetches the newest build of each target, concurrency targets at a time. A target that has never been
+// built gets the noBuilds status and one whose builds couldn't be listed is reported with its error
+func latestBuilds(ctx context.Context, buildsService *cloudbuild.BuildsService, projectId string, targets []responses.BuildTarget, concurrency int) ([]latestBuild, error) {
+	rows := make([]latestBuild, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target responses.BuildTarget) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			row := latestBuild{BuildTargetId: target.Id, Name: target.Name}
+			builds, err := buildsService.ListContext(ctx, projectId, target.Id, cloudbuild.ListBuildsOptions{Limit: 1})
+			switch {
+			case err != nil:
+				row.Error = err.Error()
+			case len(builds) == 0:
+				row.Status = noBuilds
+			default:
+				row.Number = builds[0].Number
+				row.Status = string(builds[0].Status)
+				row.Finished = builds[0].Finished
+			}
+			rows[i] = row
+		}(i, target)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return rows, err
+	}
+
+	failed := 0
+	for _, row := range rows {
+		if row.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return rows, fmt.Errorf("%d of %d build targets failed", failed, len(rows))
+	}
+	return rows, nil
+}
+
+// idempotencyTTL is how long a start attempt is remembered for its --idempotency-key
+const idempotencyTTL = 24 * time.Hour
+
+// startAttempt is the cache entry recording a start with an --idempotency-key, Number is set once the api has
+// answered with the queued build
+type startAttempt struct {
+	ProjectId     string    `json:"projectId"`
+	BuildTargetId string    `json:"buildTargetId"`
+	Sent          time.Time `json:"sent"`
+	Number        int       `json:"build,omitempty"`
+}
+
+// startBuild queues a build sending an idempotency key, the --idempotency-key flag or one generated for this run.
+// A given key is remembered so that re-running the command with it returns the build an earlier run started, even
+// when that run timed out before the api answered, rather than queuing a duplicate
+func startBuild(ctx context.Context, flags map[string]string, buildsService *cloudbuild.BuildsService, projectId, targetId string) (*responses.Build, error) {
+	opts := cloudbuild.StartOptions{Clean: boolFlag(flags, "clean"), IdempotencyKey: flags["idempotency-key"]}
+
+	if opts.IdempotencyKey == "" {
+		key, err := newIdempotencyKey()
+		if err != nil {
+			return nil, err
+		}
+		opts.IdempotencyKey = key
+		return buildsService.StartWithContext(ctx, projectId, targetId, opts)
+	}
+
+	if boolFlag(flags, "dry-run") {
+		return buildsService.StartWithContext(ctx, projectId, targetId, opts)
+	}
+
+	cacheKey := "idempotency:" + opts.IdempotencyKey
+
+	var attempt startAttempt
+	if ok, err := settings.ReadCache(cacheKey, idempotencyTTL, &attempt); err == nil && ok {
+		if attempt.ProjectId != projectId || attempt.BuildTargetId != targetId {
+			return nil, validationErrorf("--idempotency-key: %q was already used to start a build of %s/%s", opts.IdempotencyKey, attempt.ProjectId, attempt.BuildTargetId)
+		}
+
+		if attempt.Number > 0 {
+			fmt.Fprintf(progress(flags), "build %d was already started with this idempotency key\n", attempt.Number)
+			return buildsService.GetContext(ctx, projectId, targetId, attempt.Number)
+		}
+		opts.Since = attempt.Sent
+	} else {
+		attempt = startAttempt{ProjectId: projectId, BuildTargetId: targetId, Sent: time.Now()}
+		if err := settings.WriteCache(cacheKey, attempt); err != nil {
+			fmt.Fprintf(progress(flags), "could not record the idempotency key: %v\n", err)
+		}
+	}
+
+	build, err := buildsService.StartWithContext(ctx, projectId, targetId, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	attempt.Number = build.Number
+	if err := settings.WriteCache(cacheKey, attempt); err != nil {
+		fmt.Fprintf(progress(flags), "could not record the idempotency key: %v\n", err)
+	}
+	return build, nil
+}
+
+func newIdempotencyKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// waitForBuild polls a build until it finishes using the --interval and --timeout flags, status changes are
+// printed to stderr, a build that does not succeed is returned along with a BuildFailedError
+func waitForBuild(ctx context.Context, flags map[string]string, buildsService *cloudbuild.BuildsService, projectId, targetId string, buildNumber int) (*responses.Build, error) {
+	intervalVal := defaultPollInterval
+	if val, ok := flags["interval"]; ok {
+		intervalVal = val
+	}
+	interval, err := parseDuration(intervalVal)
+	if err != nil || interval <= 0 {
+		return nil, validationErrorf("--interval: invalid duration %q", intervalVal)
+	}
+
+	if val, ok := flags["timeout"]; ok && val != "0" {
+		timeout, err := parseDuration(val)
+		if err != nil || timeout < 0 {
+			return nil, validationErrorf("--timeout: invalid duration %q", val)
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	build, err := buildsService.WaitContext(ctx, projectId, targetId, buildNumber, interval, func(build *responses.Build) {
+		w := progress(flags)
+		fmt.Fprintf(w, "%s build %d: %s\n", time.Now().Format("15:04:05"), build.Number, colorStatus(w, string(build.Status)))
+	})
+	if err == context.DeadlineExceeded {
+		return nil, fmt.Errorf("timed out waiting for build %d", buildNumber)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if build.Status != responses.BuildStatusSuccess {
+		return build, &BuildFailedError{build.Number, string(build.Status)}
+	}
+	return build, nil
+}
+
+// downloadArtifact writes a build's primary artifact to out, or when out is a directory to <target>-<number><ext> in
+// it, returning the file's path. The artifact is written to a temp file that is only renamed to out once complete,
+// so an interrupted download never leaves a partial file that looks finished
+func downloadArtifact(ctx context.Context, buildsService *cloudbuild.BuildsService, projectId, targetId string, buildNumber int, out string, progress cloudbuild.ProgressFunc) (string, int64, error) {
+	artifactUrl, err := buildsService.GetArtifactURLContext(ctx, projectId, targetId, buildNumber)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if info, err := os.Stat(out); (err == nil && info.IsDir()) || strings.HasSuffix(out, string(filepath.Separator)) {
+		ext := ""
+		if u, err := url.Parse(artifactUrl); err == nil {
+			ext = path.Ext(u.Path)
+		}
+		out = filepath.Join(out, fmt.Sprintf("%s-%d%s", targetId, buildNumber, ext))
+	}
+
+	// the partial file is named after the build so a failed download is resumed by downloading it again
+	partPath := filepath.Join(filepath.Dir(out), fmt.Sprintf(".%s.%s-%d.part", filepath.Base(out), targetId, buildNumber))
+
+	written, err := buildsService.DownloadArtifactFileContext(ctx, artifactUrl, partPath, progress)
+	if err == nil {
+		err = os.Rename(partPath, out)
+	}
+	if err != nil {
+		if info, statErr := os.Stat(partPath); statErr == nil && info.Size() == 0 {
+			os.Remove(partPath)
+		}
+		return "", 0, err
+	}
+
+	return out, written, nil
+}
+
+type artifactDownload struct {
+	Number  int    `json:"build"`
+	Path    string `json:"path,omitempty"`
+	Written int64  `json:"bytes"`
+	Error   string `json:"error,omitempty"`
+}
+
+// downloadArtifacts downloads the artifacts of several builds into the directory out, concurrency at a time. A
+// failure is recorded against its build without stopping the others
+func downloadArtifacts(ctx context.Context, flags map[string]string, buildsService *cloudbuild.BuildsService, projectId, targetId string, buildNumbers []int, out string, concurrency int) ([]artifactDownload, error) {
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return nil, err
+	}
+
+	bars := newDownloadProgress(flags)
+	defer bars.done()
+
+	downloads := make([]artifactDownload, len(buildNumbers))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, number := range buildNumbers {
+		progress := bars.track(fmt.Sprintf("#%d", number))
+
+		wg.Add(1)
+		go func(i, number int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			download := artifactDownload{Number: number}
+			if path, written, err := downloadArtifact(ctx, buildsService, projectId, targetId, number, out+string(filepath.Separator), progress); err != nil {
+				download.Error = err.Error()
+			} else {
+				download.Path, download.Written = path, written
+			}
+			downloads[i] = download
+		}(i, number)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, download := range downloads {
+		if download.Error != "" {
+			failed++
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return downloads, err
+	}
+	if failed > 0 {
+		return downloads, fmt.Errorf("%d of %d downloads failed", failed, len(downloads))
+	}
+	return downloads, nil
+}
+
+// downloadProgress draws a single status line covering every download in progress, it is nil and draws nothing
+// when stderr isn't a terminal or with --quiet
+type downloadProgress struct {
+	mu     sync.Mutex
+	w      io.Writer
+	labels []string
+	state  map[string][2]int64
+	drawn  time.Time
+}
+
+const progressRedraw = 100 * time.Millisecond
+
+func newDownloadProgress(flags map[string]string) *downloadProgress {
+	if boolFlag(flags, "quiet") || !terminal.IsTerminal(int(os.Stderr.Fd())) {
+		return nil
+	}
+	return &downloadProgress{w: os.Stderr, state: make(map[string][2]int64)}
+}
+
+// track adds a download to the status line, the returned func is given to the download to report its progress
+func (p *downloadProgress) track(label string) cloudbuild.ProgressFunc {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	p.labels = append(p.labels, label)
+	p.state[label] = [2]int64{0, -1}
+	p.mu.Unlock()
+
+	return func(written, total int64) {
+		p.update(label, written, total)
+	}
+}
+
+func (p *downloadProgress) update(label string, written, total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.state[label] = [2]int64{written, total}
+	if time.Since(p.drawn) < progressRedraw && written != total {
+		return
+	}
+	p.drawn = time.Now()
+
+	parts := make([]string, 0, len(p.labels))
+	for _, l := range p.labels {
+		state := p.state[l]
+		parts = append(parts, l+" "+formatProgress(state[0], state[1]))
+	}
+	fmt.Fprintf(p.w, "\r\033[K%s", strings.Join(parts, "  "))
+}
+
+// done ends the status line so later output starts on a new line
+func (p *downloadProgress) done() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.drawn.IsZero() {
+		fmt.Fprintln(p.w)
+	}
+}
+
+func formatProgress(written, total int64) string {
+	const mb = 1024 * 1024
+	if total <= 0 {
+		return fmt.Sprintf("%.1f MB", float64(written)/mb)
+	}
+	return fmt.Sprintf("%.1f/%.1f MB (%d%%)", float64(written)/mb, float64(total)/mb, written*100/total)
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildLogOutPath(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("GET /api/v1/orgs/example/projects/my-game/buildtargets/ios/builds/42/log", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "build succeeded\n")
+	})
+
+	// a path pasted with quotes around it is cleaned up like every other path flag
+	out := filepath.Join(t.TempDir(), "build.log")
+	flags := commandFlags(t, api, map[string]string{
+		"projectId": "my-game", "buildTargetId": "ios", "buildNumber": "42", "out": "'" + out + "'",
+	})
+
+	if err := runCommand("buildLog", flags); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "build succeeded\n" {
+		t.Errorf("wrote %q, want the log", data)
+	}
+}
@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild/responses"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// buildTargetSchema describes the build target definitions read by readBuildTargetConfig
+//
+//go:embed buildtarget.schema.json
+var buildTargetSchema []byte
+
+// readBuildTargetConfig reads a build target definition, checking it against buildTargetSchema so typos and bad
+// values are reported by line and field locally instead of as a vague 400 from the api
+func readBuildTargetConfig(path string) (*cloudbuild.BuildTargetConfig, error) {
+	data, err := ioutil.ReadFile(strings.TrimSpace(path))
+	if err != nil {
+		return nil, err
+	}
+
+	problems, err := validateSchema(buildTargetSchema, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		lines := make([]string, 0, len(problems))
+		for _, problem := range problems {
+			lines = append(lines, problem.String())
+		}
+		return nil, validationErrorf("%s does not match the build target schema:\n  %s", path, strings.Join(lines, "\n  "))
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	var config cloudbuild.BuildTargetConfig
+	if err := dec.Decode(&config); err != nil {
+		return nil, validationErrorf("%s: %v", path, err)
+	}
+
+	return &config, nil
+}
+
+// buildTargetPatch builds a partial update from the optional --file and the individual field flags,
+// with the flags taking precedence
+func buildTargetPatch(flags map[string]string) (*cloudbuild.BuildTargetConfig, error) {
+	patch := &cloudbuild.BuildTargetConfig{}
+
+	if path := normalizePath(flags["file"]); path != "" {
+		if err := fileExists(path); err != nil {
+			return nil, validationErrorf("--file: %w", err)
+		}
+
+		config, err := readBuildTargetConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		patch = config
+	}
+
+	if val, ok := flags["name"]; ok {
+		patch.Name = val
+	}
+	if val, ok := flags["platform"]; ok {
+		patch.Platform = responses.Platform(val)
+	}
+	if val, ok := flags["branch"]; ok {
+		patch.Branch = val
+	}
+	if val, ok := flags["unityVersion"]; ok {
+		patch.UnityVersion = val
+	}
+	if val, ok := flags["enabled"]; ok {
+		enabled, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, validationErrorf("--enabled: must be true or false")
+		}
+		patch.Enabled = &enabled
+	}
+
+	if *patch == (cloudbuild.BuildTargetConfig{}) {
+		return nil, validationErrorf("nothing to update, pass --file or at least one of --name, --platform, --branch, --unityVersion, --enabled")
+	}
+
+	return patch, nil
+}
+
+// knownBranches returns the sorted branches the build targets of a project build from according to their scm
+// settings, the api has no way to list a repository's branches so this is the only way to check one exists
+func knownBranches(ctx context.Context, targetsService *cloudbuild.BuildTargetsService, projectId string) ([]string, error) {
+	targets, err := targetsService.ListAllContext(ctx, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var branches []string
+	for _, target := range targets {
+		if branch := target.Settings.Scm.Branch; branch != "" && !seen[branch] {
+			seen[branch] = true
+			branches = append(branches, branch)
+		}
+	}
+
+	sort.Strings(branches)
+	return branches, nil
+}
+
+// confirmBranch asks before setting a branch that no build target of the project builds from yet
+func confirmBranch(ctx context.Context, flags map[string]string, targetsService *cloudbuild.BuildTargetsService, projectId, branch string) (bool, error) {
+	branches, err := knownBranches(ctx, targetsService, projectId)
+	if err != nil {
+		return false, err
+	}
+
+	for _, known := range branches {
+		if known == branch {
+			return true, nil
+		}
+	}
+
+	return confirm(flags, fmt.Sprintf("No build target builds from %q (known branches: %s), set it anyway?", branch, orUnknown(strings.Join(branches, ", "))))
+}
+
+type branchRow struct {
+	Id     string `json:"buildtargetid"`
+	Name   string `json:"name"`
+	Branch string `json:"branch"`
+}
+
+func branchRows(targets []responses.BuildTarget) []branchRow {
+	rows := make([]branchRow, 0, len(targets))
+	for _, target := range targets {
+		rows = append(rows, branchRow{target.Id, target.Name, target.Settings.Scm.Branch})
+	}
+	return rows
+}
+
+// printedEnvVars masks the values of env vars, which are often secrets, unless --show-values is given
+func printedEnvVars(flags map[string]string, vars map[string]string) map[string]string {
+	if boolFlag(flags, "show-values") {
+		return vars
+	}
+
+	masked := make(map[string]string, len(vars))
+	for key := range vars {
+		masked[key] = cloudbuild.Redacted
+	}
+	return masked
+}
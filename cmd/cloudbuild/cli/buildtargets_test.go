@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrintedEnvVars(t *testing.T) {
+	vars := map[string]string{"API_HOST": "api.example.com", "API_TOKEN": "s3cret"}
+
+	var out bytes.Buffer
+	if err := prettyPrint(&out, outputJSON, "", printedEnvVars(map[string]string{}, vars)); err != nil {
+		t.Fatal(err)
+	}
+	if printed := out.String(); strings.Contains(printed, "s3cret") || !strings.Contains(printed, "API_TOKEN") {
+		t.Errorf("values should be masked and keys kept:\n%s", printed)
+	}
+
+	shown := printedEnvVars(map[string]string{"show-values": "true"}, vars)
+	if shown["API_TOKEN"] != "s3cret" {
+		t.Errorf("--show-values printed %q, want the value", shown["API_TOKEN"])
+	}
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"context"
+	"github.com/cmcpasserby/ucb/cmd/cloudbuild/settings"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+const (
+	testApiKey = "0123456789abcdef0123456789abcdef"
+	testOrgId  = "example"
+)
+
+// fakeApi is an httptest.Server standing in for the api, it records every request and answers them with the
+// handler registered for their method and path, anything else is a 404
+type fakeApi struct {
+	*httptest.Server
+	mu       sync.Mutex
+	routes   map[string]http.HandlerFunc
+	requests []string
+}
+
+func newFakeApi(t *testing.T) *fakeApi {
+	t.Helper()
+
+	api := &fakeApi{routes: make(map[string]http.HandlerFunc)}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.Method + " " + r.URL.Path
+
+		api.mu.Lock()
+		api.requests = append(api.requests, route)
+		handler, ok := api.routes[route]
+		api.mu.Unlock()
+
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(api.Close)
+
+	return api
+}
+
+// handle registers handler for requests like "DELETE /api/v1/orgs/example/credentials/signing/ios/id"
+func (api *fakeApi) handle(route string, handler http.HandlerFunc) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	api.routes[route] = handler
+}
+
+// received reports whether a request was made to route
+func (api *fakeApi) received(route string) bool {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	for _, req := range api.requests {
+		if req == route {
+			return true
+		}
+	}
+	return false
+}
+
+// useConfig points the settings at a temporary config file with contents for the rest of the test
+func useConfig(t *testing.T, contents string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), ".cloudbuild")
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	settings.SetFilePath(path)
+	t.Cleanup(func() { settings.SetFilePath("") })
+}
+
+// commandFlags is what ParseFlags would give a command run non-interactively and quietly against api, with args
+// added. It uses an empty config file, call useConfig afterwards for another
+func commandFlags(t *testing.T, api *fakeApi, args map[string]string) map[string]string {
+	t.Helper()
+
+	useConfig(t, "")
+
+	flags := map[string]string{
+		"apiKey":         testApiKey,
+		"orgId":          testOrgId,
+		"api-url":        api.URL + "/",
+		"no-interactive": "true",
+		"quiet":          "true",
+	}
+	for name, value := range args {
+		flags[name] = value
+	}
+	return flags
+}
+
+// runCommand runs a command's action with flags
+func runCommand(name string, flags map[string]string) error {
+	return Commands[name].Action(context.Background(), flags)
+}
+
+// setenv sets an environment variable for the rest of the test
+func setenv(t *testing.T, key, value string) {
+	old, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"golang.org/x/crypto/ssh/terminal"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+
+	// colorDefault is as long as the other colors so cells of a colored column stay aligned by tabwriter
+	colorDefault = "\x1b[39m"
+)
+
+// noColor is set by --no-color
+var noColor bool
+
+// statusColumns are the table columns whose values are colored by statusColors
+var statusColumns = map[string]bool{
+	"buildStatus": true,
+	"status":      true,
+	"result":      true,
+}
+
+// statusColors maps build statuses and check results to the color they are shown in
+var statusColors = map[string]string{
+	"success":       colorGreen,
+	"pass":          colorGreen,
+	"same":          colorGreen,
+	"failure":       colorRed,
+	"fail":          colorRed,
+	"canceled":      colorRed,
+	"queued":        colorYellow,
+	"sentToBuilder": colorYellow,
+	"started":       colorYellow,
+	"restarted":     colorYellow,
+	"skip":          colorYellow,
+	"changed":       colorYellow,
+}
+
+// colorEnabled reports if w is a terminal that colors may be written to, --no-color, a set NO_COLOR or TERM=dumb
+// turn them off
+func colorEnabled(w io.Writer) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	f, ok := w.(*os.File)
+	return ok && terminal.IsTerminal(int(f.Fd()))
+}
+
+func colorize(s, color string) string {
+	return color + s + colorReset
+}
+
+// colorStatus colors a build status or check result for w, unknown values are left as they are
+func colorStatus(w io.Writer, status string) string {
+	if !colorEnabled(w) {
+		return status
+	}
+	if color, ok := statusColors[status]; ok {
+		return colorize(status, color)
+	}
+	return status
+}
+
+// ColorError colors an error message red when it is written to a terminal on stderr
+func ColorError(msg string) string {
+	if !colorEnabled(os.Stderr) {
+		return msg
+	}
+	return colorize(msg, colorRed)
+}
+
+// colorStatusColumns colors the cells of a table's status columns, every cell in them, the header included, gets a
+// color so the escape codes don't throw off the column widths
+func colorStatusColumns(header []string, rows [][]string) {
+	for i, name := range header {
+		if !isStatusColumn(name) {
+			continue
+		}
+
+		header[i] = colorize(header[i], colorDefault)
+		for _, row := range rows {
+			color, ok := statusColors[row[i]]
+			if !ok {
+				color = colorDefault
+			}
+			row[i] = colorize(row[i], color)
+		}
+	}
+}
+
+// isStatusColumn reports if a table column, named by its header, holds statuses
+func isStatusColumn(header string) bool {
+	for name := range statusColumns {
+		if strings.EqualFold(name, header) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,29 +1,35 @@
 package cli
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
 	"github.com/cmcpasserby/ucb/cmd/cloudbuild/settings"
+	"github.com/cmcpasserby/ucb/cmd/cloudbuild/version"
 	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild/responses"
 	"gopkg.in/AlecAivazis/survey.v1"
+	"io"
 	"os"
-	"os/exec"
 	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 type Command struct {
-	Name     string
-	HelpText string
-	Flags    *flag.FlagSet
-	Action   func(flags map[string]string) error
+	Meta   CommandMeta
+	Flags  *flag.FlagSet
+	Action func(ctx context.Context, flags map[string]string) error
 }
 
 var (
-	apiKeyRe = regexp.MustCompile(`[0-9a-f]{32}`)
-	certIdRe = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	apiKeyRe   = regexp.MustCompile(`[0-9a-f]{32}`)
+	orgIdRe    = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	certIdRe   = regexp.MustCompile(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`)
+	optionIdRe = regexp.MustCompile(`\{([^{}]+)\}$`)
 
 	validators = map[string]func(v interface{}) error{
 		"apiKey": func(v interface{}) error {
@@ -39,11 +45,14 @@ var (
 			return nil
 		},
 
-		"certId": func(v interface{}) error {
-			dataErr := errors.New("invalid cert id")
+		"orgId": func(v interface{}) error {
+			dataErr := errors.New("invalid org id, only letters, numbers, '.', '-' and '_' are allowed")
 
 			if str, ok := v.(string); ok {
-				if len(str) == 0 || !certIdRe.MatchString(str) {
+				if strings.Contains(str, ",") {
+					return errors.New("this command takes a single org id")
+				}
+				if len(str) == 0 || !orgIdRe.MatchString(str) {
 					return dataErr
 				}
 			} else {
@@ -52,16 +61,44 @@ var (
 			return nil
 		},
 
-		"certPath":    fileExists,
-		"profilePass": fileExists,
+		"certId": validCertId,
+		"credId": validCertId,
+
+		"buildNumber": func(v interface{}) error {
+			if str, ok := v.(string); ok {
+				if _, err := parseBuildNumber(str); err != nil {
+					return err
+				}
+				return nil
+			}
+			return errors.New("invalid build number")
+		},
+
+		"certPath": localOrRemoteFile(cloudbuild.CheckP12),
+
+		"profilePath": localOrRemoteFile(func(path string) error {
+			_, err := cloudbuild.ReadProvisioningProfile(path)
+			return err
+		}),
+
+		"keystorePath": localOrRemoteFile(nil),
+		"url":          validHookURL,
+		"file":         fileExists,
+		"dir":          dirExists,
+		"api-url": func(v interface{}) error {
+			if str, ok := v.(string); ok {
+				return validateAPIURL(str)
+			}
+			return errors.New("invalid url")
+		},
 	}
 )
 
-func fileExists(v interface{}) error {
-	dataErr := errors.New("invalid file")
+func validCertId(v interface{}) error {
+	dataErr := errors.New("invalid cert id")
 
 	if str, ok := v.(string); ok {
-		if _, err := os.Stat(str); err != nil {
+		if len(str) == 0 || !certIdRe.MatchString(str) {
 			return dataErr
 		}
 	} else {
@@ -70,12 +107,76 @@ func fileExists(v interface{}) error {
 	return nil
 }
 
+// fileExists checks a path names a readable file, the error is a FileError saying which path and why
+func fileExists(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return errors.New("invalid file")
+	}
+	path := normalizePath(str)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return &FileError{path, err}
+	}
+	if info.IsDir() {
+		return &FileError{path, errIsDir}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return &FileError{path, err}
+	}
+	f.Close()
+
+	return nil
+}
+
+// dirExists checks a path names a directory, the error is a FileError saying which path and why
+func dirExists(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return errors.New("invalid directory")
+	}
+	path := normalizePath(str)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return &FileError{path, err}
+	}
+	if !info.IsDir() {
+		return &FileError{path, errNotDir}
+	}
+	return nil
+}
+
+func parseBuildNumber(str string) (int, error) {
+	number, err := strconv.Atoi(strings.TrimSpace(str))
+	if err != nil || number <= 0 {
+		return 0, validationErrorf("invalid build number")
+	}
+	return number, nil
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+func missingFlagsError(names []string) error {
+	return validationErrorf("running non-interactively, missing required flags: --%s", strings.Join(names, ", --"))
+}
+
 func populateGlobalArgs(flags map[string]string, data interface{}) error {
 	v := reflect.Indirect(reflect.ValueOf(data))
 	tt := v.Type()
 	fCount := v.NumField()
 
 	qs := make([]*survey.Question, 0, fCount)
+	interactive := isInteractive(flags)
+	var missing []string
 
 	for i := 0; i < fCount; i++ {
 		if isGlobal := tt.Field(i).Tag.Get("global"); isGlobal == "" || isGlobal == "false" {
@@ -88,7 +189,14 @@ func populateGlobalArgs(flags map[string]string, data interface{}) error {
 		}
 
 		if val, ok := flags[fName]; ok && val != "" {
+			if validator, ok := validators[fName]; ok {
+				if err := validator(val); err != nil {
+					return validationErrorf("%s: %v", fName, err)
+				}
+			}
 			v.Field(i).SetString(val)
+		} else if !interactive {
+			missing = append(missing, fName)
 		} else {
 			validator, ok := validators[fName]
 			if !ok {
@@ -103,22 +211,31 @@ func populateGlobalArgs(flags map[string]string, data interface{}) error {
 		}
 	}
 
+	if len(missing) > 0 {
+		return missingFlagsError(missing)
+	}
+
 	if len(qs) > 0 {
 		if err := survey.Ask(qs, data); err != nil {
 			return err
 		}
 	}
+
+	registerSecrets(data)
 	return nil
 }
 
-func populateArgs(flags map[string]string, data interface{}, credsService *cloudbuild.CredentialsService) error {
+func populateArgs(ctx context.Context, flags map[string]string, data interface{}, credsService *cloudbuild.CredentialsService) error {
 	v := reflect.Indirect(reflect.ValueOf(data))
 	tt := v.Type()
 	fCount := v.NumField()
 
 	qs := make([]*survey.Question, 0, fCount)
+	interactive := isInteractive(flags)
+	var missing []string
 
-	hasInteractiveCert := false
+	var promptedCerts []int // certId fields answered with a "label {id}" option or typed in
+	hasInteractiveProject := false
 
 	for i := 0; i < fCount; i++ {
 		if isGlobal := tt.Field(i).Tag.Get("global"); isGlobal == "true" {
@@ -135,8 +252,31 @@ func populateArgs(flags map[string]string, data interface{}, credsService *cloud
 			continue
 		}
 
+		if fType == "password" {
+			secret, found, err := passwordFromFlags(flags, fName)
+			if err != nil {
+				return err
+			}
+
+			if found {
+				v.Field(i).SetString(secret)
+				continue
+			}
+		}
+
 		if val, ok := flags[fName]; ok {
+			if validator, ok := validators[fName]; ok {
+				if err := validator(val); err != nil {
+					return validationErrorf("--%s: %w", fName, err)
+				}
+			}
+			// only prompted ids are cut out of what was answered, a flag must be the id alone
+			if fType == "certId" && certIdRe.FindString(val) != val {
+				return validationErrorf("--%s: %q is not a credential id, expected eg 0a1b2c3d-4e5f-6789-abcd-ef0123456789", fName, val)
+			}
 			v.Field(i).SetString(val)
+		} else if !interactive {
+			missing = append(missing, fName)
 		} else {
 			var promptType survey.Prompt
 
@@ -144,24 +284,38 @@ func populateArgs(flags map[string]string, data interface{}, credsService *cloud
 				promptType = &survey.Password{Message: fName}
 			} else if fType == "filePath" {
 				promptType = &survey.Input{Message: fmt.Sprintf("%s (absoulte path, can drag and drop)", fName)}
-			} else if fType == "certId" {
-				hasInteractiveCert = true
+			} else if fName == "projectId" {
+				hasInteractiveProject = true
 
-				creds, err := credsService.GetAllIOS()
+				options, err := projectOptions(ctx, flags, data)
 				if err != nil {
-					return err // maybe fallback on manual text input instead of error
+					// fallback on manual input so a failed listing doesn't block using a known id
+					fmt.Fprintf(progress(flags), "could not list projects: %v\n", err)
+					promptType = &survey.Input{Message: fName}
+				} else {
+					promptType = &survey.Select{
+						Message:  fName,
+						Options:  options,
+						PageSize: 10,
+					}
 				}
+			} else if fType == "certId" {
+				promptedCerts = append(promptedCerts, i)
 
-				options := make([]string, 0, len(creds))
-
-				for _, cred := range creds {
-					options = append(options, fmt.Sprintf("%s {%s}", cred.Label, cred.Id))
-				}
+				platform := responses.Platform(tt.Field(i).Tag.Get("platform"))
 
-				promptType = &survey.Select{
-					Message:  fName,
-					Options:  options,
-					PageSize: 10,
+				options, err := credOptions(ctx, credsService, platform)
+				if err != nil {
+					// fallback on manual input so a failed listing doesn't block using a known id
+					fmt.Fprintf(progress(flags), "could not list credentials: %v\n", err)
+					promptType = &survey.Input{Message: fName}
+				} else {
+					promptType = &survey.Select{
+						Message:  fName,
+						Options:  options,
+						PageSize: 10,
+						Help:     "type part of a label or id to narrow the list, backspace or ctrl+u to widen it again",
+					}
 				}
 			} else {
 				promptType = &survey.Input{Message: fName}
@@ -180,47 +334,193 @@ func populateArgs(flags map[string]string, data interface{}, credsService *cloud
 		}
 	}
 
+	if len(missing) > 0 {
+		return missingFlagsError(missing)
+	}
+
 	if err := survey.Ask(qs, data); err != nil {
 		return err
 	}
 
-	if hasInteractiveCert {
+	for _, i := range promptedCerts {
+		v.Field(i).SetString(certIdRe.FindString(v.Field(i).String()))
+	}
+
+	if hasInteractiveProject {
 		for i := 0; i < fCount; i++ {
-			fType := tt.Field(i).Tag.Get("type")
-			if fType != "certId" {
+			if tt.Field(i).Tag.Get("survey") != "projectId" {
 				continue
 			}
 
-			oldValue := v.Field(i).String()
-			v.Field(i).SetString(certIdRe.FindString(oldValue))
+			if match := optionIdRe.FindStringSubmatch(v.Field(i).String()); match != nil {
+				v.Field(i).SetString(match[1])
+			}
+		}
+	}
+
+	normalizePaths(data)
+
+	if err := downloadRemoteFiles(ctx, data); err != nil {
+		return err
+	}
+
+	registerSecrets(data)
+	return nil
+}
+
+// downloadRemoteFiles replaces file path fields given as urls with downloaded temp files, then validates them
+func downloadRemoteFiles(ctx context.Context, data interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	tt := v.Type()
+
+	for i := 0; i < v.NumField(); i++ {
+		if tt.Field(i).Tag.Get("type") != "filePath" || !isURL(v.Field(i).String()) {
+			continue
+		}
+
+		fName := tt.Field(i).Tag.Get("survey")
+		if fName == "" {
+			fName = tt.Field(i).Name
+		}
+
+		tempPath, err := downloadTemp(ctx, v.Field(i).String())
+		if err != nil {
+			return fmt.Errorf("--%s: %v", fName, err)
 		}
+
+		if validator, ok := validators[fName]; ok {
+			if err := validator(tempPath); err != nil {
+				return validationErrorf("--%s: %w", fName, err)
+			}
+		}
+		v.Field(i).SetString(tempPath)
 	}
 
 	return nil
 }
 
-var CommandOrder = [...]string{"getCred", "listCreds", "updateCred", "uploadCred", "deleteCred", "listProjects", "config"}
+// credOptions lists the credentials of a platform as "label {id}" sorted by label, the select they're shown in
+// filters them by whatever is typed
+func credOptions(ctx context.Context, credsService *cloudbuild.CredentialsService, platform responses.Platform) ([]string, error) {
+	var options []string
+
+	if platform == responses.PlatformAndroid {
+		creds, err := credsService.GetAllAndroidContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cred := range creds {
+			options = append(options, fmt.Sprintf("%s {%s}", cred.Label, cred.Id))
+		}
+	} else {
+		creds, err := credsService.GetAllIOSContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cred := range creds {
+			options = append(options, fmt.Sprintf("%s {%s}", cred.Label, cred.Id))
+		}
+	}
+
+	sort.SliceStable(options, func(i, j int) bool {
+		return strings.ToLower(options[i]) < strings.ToLower(options[j])
+	})
+	return options, nil
+}
+
+// projectOptions lists the org's projects as "name {guid}" using the api key and org id already set in data
+func projectOptions(ctx context.Context, flags map[string]string, data interface{}) ([]string, error) {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	tt := v.Type()
+
+	var apiKey, orgId string
+	for i := 0; i < v.NumField(); i++ {
+		switch tt.Field(i).Tag.Get("survey") {
+		case "apiKey":
+			apiKey = v.Field(i).String()
+		case "orgId":
+			orgId = v.Field(i).String()
+		}
+	}
+
+	projectService := cloudbuild.NewProjectsService(apiKey, orgId, serviceOptions(flags)...)
+	projects, err := projectService.ListAllContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(projects) == 0 {
+		return nil, errors.New("the org has no projects")
+	}
+
+	options := make([]string, 0, len(projects))
+	for _, proj := range projects {
+		options = append(options, fmt.Sprintf("%s {%s}", proj.Name, proj.Guid))
+	}
+	return options, nil
+}
+
+// setupConfig prompts for the default api key and org id and saves them to the config file
+func setupConfig(flags map[string]string) error {
+	results := struct {
+		ApiKey string `survey:"apiKey"`
+		OrgId  string `survey:"orgId"`
+	}{flags["apiKey"], flags["orgId"]}
+
+	if isInteractive(flags) {
+		qs := []*survey.Question{
+			{
+				Name:     "apiKey",
+				Prompt:   &survey.Input{Message: "apiKey", Default: results.ApiKey},
+				Validate: validators["apiKey"],
+			},
+			{
+				Name:     "orgId",
+				Prompt:   &survey.Input{Message: "orgId", Default: results.OrgId},
+				Validate: validators["orgId"],
+			},
+		}
+
+		if err := survey.Ask(qs, &results); err != nil {
+			return err
+		}
+	}
+
+	if err := validators["apiKey"](results.ApiKey); err != nil {
+		return &ValidationError{err}
+	}
+
+	if results.OrgId == "" {
+		return missingFlagsError([]string{"orgId"})
+	}
 
-func prettyPrint(data interface{}) {
-	if s, err := json.MarshalIndent(data, "", "    "); err == nil {
-		fmt.Println(string(s))
-		return
+	if err := validators["orgId"](results.OrgId); err != nil {
+		return &ValidationError{err}
 	}
 
-	fmt.Printf("%+v\n", data)
+	return settings.SetCredentials(flags["profile"], results.ApiKey, results.OrgId)
 }
 
+var CommandOrder = [...]string{"getCred", "listCreds", "updateCred", "diffCred", "uploadCred", "uploadCredToTargets", "assignCred", "copyCred", "renameCred", "renewProfiles", "deleteCred", "deleteCredsMatching", "exportCreds", "importCreds", "getAndroidCred", "listAndroidCreds", "updateAndroidCred", "uploadAndroidCred", "renameAndroidCred", "deleteAndroidCred", "listProjects", "projectsReport", "listBuildTargets", "listAllBuildTargets", "getBuildTarget", "createBuildTarget", "updateBuildTarget", "listBranches", "setBranch", "deleteBuildTarget", "clearBuildCache", "getEnvVars", "setEnvVar", "deleteEnvVar", "startBuild", "build", "listBuilds", "projectBuilds", "getBuild", "statusBuild", "waitBuild", "buildLog", "cancelBuild", "downloadBuild", "shareBuild", "revokeShareLink", "listHooks", "createHook", "deleteHook", "whoami", "validateConfig", "flushQueue", "auditLog", "favorite", "config", "completion", "version"}
+
 var Commands = map[string]Command{
 
 	"getCred": {
-		"getCred",
-		"Get IOS Credential Detials",
+		CommandMeta{
+			Name:    "getCred",
+			Summary: "Get IOS Credential Detials",
+			Examples: []string{
+				"ucb getCred --credId 0a1b2c3d-4e5f-6789-abcd-ef0123456789",
+			},
+		},
 		func() *flag.FlagSet {
 			flags := CreateFlagSet("getCred")
 			flags.String("credId", "", "Credential Id")
 			return flags
 		}(),
-		func(flags map[string]string) error {
+		func(ctx context.Context, flags map[string]string) error {
 			results := struct {
 				ApiKey string `survey:"apiKey" global:"true"`
 				OrgId  string `survey:"orgId" global:"true"`
@@ -231,29 +531,43 @@ var Commands = map[string]Command{
 				return err
 			}
 
-			credsService := cloudbuild.NewCredentialsService(flags["apiKey"], flags["orgId"])
-			if err := populateArgs(flags, &results, credsService); err != nil {
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := populateArgs(ctx, flags, &results, credsService); err != nil {
 				return err
 			}
 
-			cred, err := credsService.GetIOS(results.CredId)
+			cred, err := credsService.GetIOSContext(ctx, results.CredId)
 			if err != nil {
 				return err
 			}
 
-			prettyPrint(cred)
-
-			return nil
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], cred)
 		},
 	},
 
 	"listCreds": {
-		"listCreds",
-		"List all IOS Credentials",
+		CommandMeta{
+			Name:    "listCreds",
+			Summary: "List all IOS Credentials",
+			Examples: []string{
+				"ucb listCreds --output table",
+				"ucb listCreds --output csv > credentials.csv",
+				"ucb listCreds --sort expiry --expiring-within 30d --output table",
+				"ucb listCreds --orgId studio-a,studio-b --output table",
+			},
+		},
 		func() *flag.FlagSet {
-			return CreateFlagSet("listCreds")
+			flags := CreateFlagSet("listCreds")
+			flags.String("sort", "", "Sort credentials by expiry or label")
+			flags.String("expiring-within", "", "Only list credentials that have expired or expire within this window, eg 30d")
+			flags.Int("page-size", cloudbuild.DefaultPageSize, fmt.Sprintf("Results fetched per request, at most %d", cloudbuild.MaxPageSize))
+			return flags
 		}(),
-		func(flags map[string]string) error {
+		func(ctx context.Context, flags map[string]string) error {
+			if orgs := orgIds(flags); len(orgs) > 1 {
+				return listOrgs(ctx, flags, flags["output"], orgs, iosCredListing)
+			}
+
 			// parse args and settings, and question if needed
 			results := struct {
 				ApiKey string `survey:"apiKey" global:"true"`
@@ -264,35 +578,43 @@ var Commands = map[string]Command{
 				return err
 			}
 
-			if err := populateArgs(flags, &results, nil); err != nil {
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
 				return err
 			}
 
-			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId)
-			creds, err := credsService.GetAllIOS()
+			creds, err := iosCredListing(ctx, flags, results.ApiKey, results.OrgId)
 			if err != nil {
 				return err
 			}
 
-			prettyPrint(creds)
-
-			return nil
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], creds)
 		},
 	},
 
 	"updateCred": {
-		"updateCred",
-		"Update a IOS Credential",
+		CommandMeta{
+			Name:        "updateCred",
+			Summary:     "Update a IOS Credential",
+			Description: "The local files are compared with the uploaded credential first, when they match and the label is the same nothing is updated unless --update-unchanged is given.",
+			Examples: []string{
+				"ucb updateCred --certId 0a1b2c3d-4e5f-6789-abcd-ef0123456789 --label release --certPath dist.p12 --profilePath release.mobileprovision --certPass-file pass.txt",
+				"ucb updateCred --certId 0a1b2c3d-4e5f-6789-abcd-ef0123456789 --label release --certPath dist.p12 --profilePath release.mobileprovision --update-unchanged",
+			},
+		},
 		func() *flag.FlagSet {
 			flags := CreateFlagSet("updateCred")
 			flags.String("certId", "", "Certificate Id")
 			flags.String("label", "", "Label")
 			flags.String("certPath", "", "Certificate Path")
 			flags.String("profilePath", "", "Provisioning Profile Path")
-			flags.String("certPass", "", "Certificate password")
+			flags.String("certPass", "", "Certificate password, - reads it from stdin")
+			flags.String("certPass-file", "", "File to read the certificate password from, - reads it from stdin")
+			flags.Bool("strict", false, "Fail instead of warning when the certificate or profile is expiring")
+			flags.String("expiry-window", "30d", "Warn when the certificate or profile expires within this window")
+			flags.Bool("update-unchanged", false, "Update the credential even if the local files match the uploaded one")
 			return flags
 		}(),
-		func(flags map[string]string) error {
+		func(ctx context.Context, flags map[string]string) error {
 			results := struct {
 				ApiKey      string `survey:"apiKey" global:"true"`
 				OrgId       string `survey:"orgId" global:"true"`
@@ -307,34 +629,126 @@ var Commands = map[string]Command{
 				return err
 			}
 
-			credsService := cloudbuild.NewCredentialsService(flags["apiKey"], flags["orgId"])
-			if err := populateArgs(flags, &results, credsService); err != nil {
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := populateArgs(ctx, flags, &results, credsService); err != nil {
 				return err
 			}
 
-			cred, err := credsService.UpdateIOS(results.CertId, results.Label, results.CertPath, results.ProfilePath, results.CertPass)
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			if err := checkSigningExpiry(flags, results.CertPath, results.CertPass, results.ProfilePath); err != nil {
+				return err
+			}
+
+			if !boolFlag(flags, "update-unchanged") {
+				uploaded, err := credsService.GetIOSContext(ctx, results.CertId)
+				if err != nil {
+					return err
+				}
+
+				if diffs, err := diffIOSCred(uploaded, results.CertPath, results.CertPass, results.ProfilePath); err != nil {
+					fmt.Fprintf(progress(flags), "warning: could not compare with the uploaded credential: %v\n", err)
+				} else if !credChanged(diffs) && uploaded.Label == results.Label {
+					fmt.Fprintf(progress(flags), "%s already matches the local files, not updating it (--update-unchanged updates anyway)\n", results.CertId)
+					return prettyPrint(stdout(flags), flags["output"], flags["fields"], uploaded)
+				}
+			}
+
+			cred, err := credsService.UpdateIOSContext(ctx, results.CertId, results.Label, results.CertPath, results.ProfilePath, results.CertPass)
 			if err != nil {
 				return err
 			}
 
-			prettyPrint(cred)
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], cred)
+		},
+	},
 
-			return nil
+	"diffCred": {
+		CommandMeta{
+			Name:        "diffCred",
+			Summary:     "Compare Local Signing Files with an Uploaded IOS Credential",
+			Description: "Each value of the certificate and profile is printed next to the uploaded one, values the api doesn't return are shown as unknown.",
+			Examples: []string{
+				"ucb diffCred --certId 0a1b2c3d-4e5f-6789-abcd-ef0123456789 --certPath dist.p12 --profilePath release.mobileprovision --certPass-file pass.txt",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("diffCred")
+			flags.String("certId", "", "Certificate Id")
+			flags.String("certPath", "", "Certificate Path")
+			flags.String("profilePath", "", "Provisioning Profile Path")
+			flags.String("certPass", "", "Certificate password, - reads it from stdin")
+			flags.String("certPass-file", "", "File to read the certificate password from, - reads it from stdin")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey      string `survey:"apiKey" global:"true"`
+				OrgId       string `survey:"orgId" global:"true"`
+				CertId      string `survey:"certId" type:"certId"`
+				CertPath    string `survey:"certPath" type:"filePath"`
+				ProfilePath string `survey:"profilePath" type:"filePath"`
+				CertPass    string `survey:"certPass" type:"password"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := populateArgs(ctx, flags, &results, credsService); err != nil {
+				return err
+			}
+
+			cred, err := credsService.GetIOSContext(ctx, results.CertId)
+			if err != nil {
+				return err
+			}
+
+			diffs, err := diffIOSCred(cred, results.CertPath, results.CertPass, results.ProfilePath)
+			if err != nil {
+				return err
+			}
+
+			if credChanged(diffs) {
+				fmt.Fprintf(progress(flags), "updateCred would replace %s's files\n", results.CertId)
+			} else {
+				fmt.Fprintf(progress(flags), "%s matches the local files, updateCred would skip it\n", results.CertId)
+			}
+
+			format := flags["output"]
+			if format == "" {
+				format = outputTable
+			}
+			return prettyPrint(stdout(flags), format, flags["fields"], diffs)
 		},
 	},
 
 	"uploadCred": {
-		"uploadCred",
-		"Upload a IOS Credential",
+		CommandMeta{
+			Name:        "uploadCred",
+			Summary:     "Upload a IOS Credential",
+			Description: "The certificate and profile are checked for expiry before uploading. With --update-if-exists a credential already using the label is updated instead of failing.",
+			Examples: []string{
+				"ucb uploadCred --label release --certPath dist.p12 --profilePath release.mobileprovision --certPass -",
+				"ucb uploadCred --label release --certPath dist.p12 --profilePath release.mobileprovision --update-if-exists",
+			},
+		},
 		func() *flag.FlagSet {
 			flags := CreateFlagSet("uploadCred")
 			flags.String("label", "", "Label")
+			flags.Bool("update-if-exists", false, "Update the credential with the same label if there already is one")
 			flags.String("certPath", "", "Certificate Path")
 			flags.String("profilePath", "", "Provisioning Profile Path")
-			flags.String("certPass", "", "Certificate password")
+			flags.String("certPass", "", "Certificate password, - reads it from stdin")
+			flags.String("certPass-file", "", "File to read the certificate password from, - reads it from stdin")
+			flags.Bool("strict", false, "Fail instead of warning when the certificate or profile is expiring")
+			flags.String("expiry-window", "30d", "Warn when the certificate or profile expires within this window")
 			return flags
 		}(),
-		func(flags map[string]string) error {
+		func(ctx context.Context, flags map[string]string) error {
 			results := struct {
 				ApiKey      string `survey:"apiKey" global:"true"`
 				OrgId       string `survey:"orgId" global:"true"`
@@ -348,112 +762,2792 @@ var Commands = map[string]Command{
 				return err
 			}
 
-			credsService := cloudbuild.NewCredentialsService(flags["apiKey"], flags["orgId"])
-			if err := populateArgs(flags, &results, credsService); err != nil {
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := populateArgs(ctx, flags, &results, credsService); err != nil {
 				return err
 			}
 
-			cred, err := credsService.UploadIOS(results.Label, results.CertPath, results.ProfilePath, results.CertPass)
-			if err != nil {
+			if err := checkSigningExpiry(flags, results.CertPath, results.CertPass, results.ProfilePath); err != nil {
 				return err
 			}
 
-			prettyPrint(cred)
+			// updating replaces an existing credential's signing files
+			if boolFlag(flags, "update-if-exists") {
+				if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+					return err
+				}
+			}
+
+			var cred *responses.IOSCred
+			var err error
+			if boolFlag(flags, "update-if-exists") {
+				cred, err = uploadOrUpdateIOS(ctx, flags, credsService, results.Label, results.CertPath, results.ProfilePath, results.CertPass)
+			} else {
+				cred, err = credsService.UploadIOSContext(ctx, results.Label, results.CertPath, results.ProfilePath, results.CertPass)
+			}
+			if err != nil {
+				return err
+			}
 
-			return nil
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], cred)
 		},
 	},
 
-	"deleteCred": {
-		"deleteCred",
-		"Delete a IOS Credential",
+	"uploadCredToTargets": {
+		CommandMeta{
+			Name:        "uploadCredToTargets",
+			Summary:     "Upload a IOS Credential and assign it to several Build Targets",
+			Description: "The credential is uploaded once and then assigned to each build target, a target that can't be assigned is reported without stopping the others.",
+			Examples: []string{
+				"ucb uploadCredToTargets --label release --certPath dist.p12 --profilePath release.mobileprovision --projectId my-game --buildTargetIds ios-release,ios-beta",
+			},
+		},
 		func() *flag.FlagSet {
-			flags := CreateFlagSet("deleteCred")
-			flags.String("credId", "", "Credential Id")
+			flags := CreateFlagSet("uploadCredToTargets")
+			flags.String("label", "", "Label")
+			flags.String("certPath", "", "Certificate Path")
+			flags.String("profilePath", "", "Provisioning Profile Path")
+			flags.String("certPass", "", "Certificate password, - reads it from stdin")
+			flags.String("certPass-file", "", "File to read the certificate password from, - reads it from stdin")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetIds", "", "Comma separated ids of the build targets to sign with the credential")
+			flags.Bool("strict", false, "Fail instead of warning when the certificate or profile is expiring")
+			flags.String("expiry-window", "30d", "Warn when the certificate or profile expires within this window")
 			return flags
 		}(),
-		func(flags map[string]string) error {
+		func(ctx context.Context, flags map[string]string) error {
 			results := struct {
-				ApiKey string `survey:"apiKey" global:"true"`
-				OrgId  string `survey:"orgId" global:"true"`
-				CertId string `survey:"certId" type:"certId"`
+				ApiKey         string `survey:"apiKey" global:"true"`
+				OrgId          string `survey:"orgId" global:"true"`
+				Label          string `survey:"label"`
+				CertPath       string `survey:"certPath" type:"filePath"`
+				ProfilePath    string `survey:"profilePath" type:"filePath"`
+				CertPass       string `survey:"certPass" type:"password"`
+				ProjectId      string `survey:"projectId"`
+				BuildTargetIds string `survey:"buildTargetIds"`
 			}{}
 
 			if err := populateGlobalArgs(flags, &results); err != nil {
 				return err
 			}
 
-			credsService := cloudbuild.NewCredentialsService(flags["apiKey"], flags["orgId"])
-			if err := populateArgs(flags, &results, credsService); err != nil {
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			targetIds := splitList(results.BuildTargetIds)
+			if len(targetIds) == 0 {
+				return validationErrorf("--buildTargetIds: no build target ids given")
+			}
+
+			if err := checkSigningExpiry(flags, results.CertPath, results.CertPass, results.ProfilePath); err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
 				return err
 			}
 
-			resp, err := credsService.DeleteIOS(results.CertId)
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			cred, err := credsService.UploadIOSContext(ctx, results.Label, results.CertPath, results.ProfilePath, results.CertPass)
 			if err != nil {
 				return err
 			}
 
-			fmt.Println(resp.Status)
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			assignments, assignErr := assignToTargets(ctx, targetsService, results.ProjectId, cred.Id, targetIds)
+			if assignErr == cloudbuild.ErrDryRun {
+				return assignErr
+			}
 
-			return nil
+			if err := prettyPrint(stdout(flags), flags["output"], flags["fields"], credAssignments{cred, assignments}); err != nil {
+				return err
+			}
+			return assignErr
 		},
 	},
 
-	"listProjects": {
-		"listProjects",
-		"List Projects On CloudBuild",
+	"assignCred": {
+		CommandMeta{
+			Name:    "assignCred",
+			Summary: "Sign a Build Target's Builds with an Existing IOS Credential",
+			Examples: []string{
+				"ucb assignCred --projectId my-game --buildTargetId ios-release --certId 0a1b2c3d-4e5f-6789-abcd-ef0123456789",
+			},
+		},
 		func() *flag.FlagSet {
-			flags := CreateFlagSet("listProjects")
+			flags := CreateFlagSet("assignCred")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.String("certId", "", "Certificate Id")
 			return flags
 		}(),
-		func(flags map[string]string) error {
+		func(ctx context.Context, flags map[string]string) error {
 			results := struct {
-				ApiKey string `survey:"apiKey" global:"true"`
-				OrgId  string `survey:"orgId" global:"true"`
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+				CertId        string `survey:"certId" type:"certId"`
 			}{}
 
 			if err := populateGlobalArgs(flags, &results); err != nil {
 				return err
 			}
 
-			if err := populateArgs(flags, &results, nil); err != nil {
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := populateArgs(ctx, flags, &results, credsService); err != nil {
 				return err
 			}
 
-			projectService := cloudbuild.NewProjectsService(results.ApiKey, results.OrgId)
-			projects, err := projectService.ListAll()
-			if err != nil {
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
 				return err
 			}
 
-			for _, proj := range projects {
-				fmt.Printf("Name: %s || Id: %s\n", proj.Name, proj.Guid)
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			target, err := targetsService.SetIOSCredentialContext(ctx, results.ProjectId, results.BuildTargetId, results.CertId)
+			if err != nil {
+				return err
 			}
 
-			return nil
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], target)
 		},
 	},
 
-	"config": { // TODO create flow for creating file via survey
-		"config",
-		"Edit config file",
+	"copyCred": {
+		CommandMeta{
+			Name:        "copyCred",
+			Summary:     "Upload a New IOS Credential Based on an Existing One",
+			Description: "The new credential takes the label of the copied one unless --label is given. The signing files and password can't be read back from the api so they have to be given again.",
+			Examples: []string{
+				"ucb copyCred --credId 0a1b2c3d-4e5f-6789-abcd-ef0123456789 --label staging --certPath dist.p12 --profilePath staging.mobileprovision",
+			},
+		},
 		func() *flag.FlagSet {
-			return flag.NewFlagSet("config", flag.ExitOnError)
+			flags := CreateFlagSet("copyCred")
+			flags.String("credId", "", "Id of the credential to copy")
+			flags.String("label", "", "Label for the new credential, defaults to the copied credential's label")
+			flags.String("certPath", "", "Certificate Path")
+			flags.String("profilePath", "", "Provisioning Profile Path")
+			flags.String("certPass", "", "Certificate password, - reads it from stdin")
+			flags.String("certPass-file", "", "File to read the certificate password from, - reads it from stdin")
+			flags.Bool("strict", false, "Fail instead of warning when the certificate or profile is expiring")
+			flags.String("expiry-window", "30d", "Warn when the certificate or profile expires within this window")
+			return flags
 		}(),
-		func(flags map[string]string) error {
-			dotFilePath, err := settings.GetFilePath()
-			if err != nil {
+		func(ctx context.Context, flags map[string]string) error {
+			source := struct {
+				ApiKey string `survey:"apiKey" global:"true"`
+				OrgId  string `survey:"orgId" global:"true"`
+				CredId string `survey:"credId" type:"certId"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &source); err != nil {
 				return err
 			}
 
-			if _, err := os.Stat(dotFilePath); os.IsNotExist(err) {
-				if err := settings.CreateDotFile(dotFilePath); err != nil {
-					return err
-				}
+			credsService := cloudbuild.NewCredentialsService(source.ApiKey, source.OrgId, serviceOptions(flags)...)
+			if err := populateArgs(ctx, flags, &source, credsService); err != nil {
+				return err
+			}
+
+			sourceCred, err := credsService.GetIOSContext(ctx, source.CredId)
+			if err != nil {
+				return err
 			}
 
-			// TODO will need to sort out platforms tools so this works on windows as well as the current mac and linux support
-			cmd := exec.Command("vim", dotFilePath)
+			// the signing files and password can't be read back from the api so they have to be given again
+			fmt.Fprintf(progress(flags), "copying %q (certificate %s, team %s)\n", sourceCred.Label, sourceCred.Certificate.Name, sourceCred.Certificate.TeamId)
+
+			results := struct {
+				CertPath    string `survey:"certPath" type:"filePath"`
+				ProfilePath string `survey:"profilePath" type:"filePath"`
+				CertPass    string `survey:"certPass" type:"password"`
+			}{}
+
+			if err := populateArgs(ctx, flags, &results, credsService); err != nil {
+				return err
+			}
+
+			label := flags["label"]
+			if label == "" {
+				label = sourceCred.Label
+			}
+
+			if err := checkSigningExpiry(flags, results.CertPath, results.CertPass, results.ProfilePath); err != nil {
+				return err
+			}
+
+			cred, err := credsService.UploadIOSContext(ctx, label, results.CertPath, results.ProfilePath, results.CertPass)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], cred)
+		},
+	},
+
+	"renewProfiles": {
+		CommandMeta{
+			Name:        "renewProfiles",
+			Summary:     "Update the IOS Credentials labelled after the .p12 and .mobileprovision pairs in a directory",
+			Description: "Each <label>.p12 and <label>.mobileprovision pair in --dir is matched to the one credential with that label. The plan is printed and confirmed before anything is updated, pairs without a match are skipped.",
+			Examples: []string{
+				"ucb renewProfiles --dir ./profiles --certPass-file pass.txt",
+				"ucb renewProfiles --dir ./profiles --certPass - --yes",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("renewProfiles")
+			flags.String("dir", "", "Directory of <label>.p12 and <label>.mobileprovision files")
+			flags.String("certPass", "", "Password of the certificates, - reads it from stdin")
+			flags.String("certPass-file", "", "File to read the certificate password from, - reads it from stdin")
+			flags.Bool("yes", false, "Update the credentials without asking for confirmation")
+			flags.Bool("strict", false, "Fail instead of warning when a certificate or profile is expiring")
+			flags.String("expiry-window", "30d", "Warn when a certificate or profile expires within this window")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey   string `survey:"apiKey" global:"true"`
+				OrgId    string `survey:"orgId" global:"true"`
+				Dir      string `survey:"dir" type:"filePath"`
+				CertPass string `survey:"certPass" type:"password"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			creds, err := credsService.GetAllIOSContext(ctx)
+			if err != nil {
+				return err
+			}
+
+			plan, err := renewalPlan(results.Dir, creds)
+			if err != nil {
+				return err
+			}
+
+			updates := 0
+			for _, pair := range plan {
+				if pair.Skip == "" {
+					updates++
+				}
+			}
+
+			if err := prettyPrint(progress(flags), outputTable, "", plan); err != nil {
+				return err
+			}
+			if updates == 0 {
+				return validationErrorf("no credentials to update in %s", results.Dir)
+			}
+
+			ok, err := confirm(flags, fmt.Sprintf("Update %d credentials?", updates))
+			if err != nil || !ok {
+				return err
+			}
+
+			renewed, renewErr := renewCreds(ctx, flags, credsService, plan, results.CertPass)
+			if renewErr == cloudbuild.ErrDryRun {
+				return renewErr
+			}
+
+			if err := prettyPrint(stdout(flags), flags["output"], flags["fields"], renewed); err != nil {
+				return err
+			}
+			return renewErr
+		},
+	},
+
+	"exportCreds": {
+		CommandMeta{
+			Name:        "exportCreds",
+			Summary:     "Export the metadata of every IOS and Android Credential in an org as one JSON document",
+			Description: "Only what the api returns is exported so there are no private keys or passwords in it, add certPath and profilePath to an entry for importCreds to upload its files.",
+			Examples: []string{
+				"ucb exportCreds --out org.json",
+				"ucb exportCreds --orgId old-org --out - | jq '.ios[].label'",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("exportCreds")
+			flags.String("out", "-", "Path to write the export to, - writes it to stdout")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey string `survey:"apiKey" global:"true"`
+				OrgId  string `survey:"orgId" global:"true"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			export, err := exportCreds(ctx, credsService, results.OrgId)
+			if err != nil {
+				return err
+			}
+
+			out := flags["out"]
+			if out == "" {
+				out = "-"
+			} else if out != "-" {
+				out = normalizePath(out)
+			}
+
+			if err := writeExport(stdout(flags), out, export); err != nil {
+				return err
+			}
+			if out != "-" {
+				fmt.Fprintf(progress(flags), "exported %d ios and %d android credentials to %s\n", len(export.IOS), len(export.Android), out)
+			}
+			return nil
+		},
+	},
+
+	"importCreds": {
+		CommandMeta{
+			Name:        "importCreds",
+			Summary:     "Upload the IOS Credentials of an exportCreds file to an org, from the signing files it or --dir points to",
+			Description: "A credential already labelled the same way in the org is updated instead of uploaded again, entries without signing files are skipped.",
+			Examples: []string{
+				"ucb importCreds --orgId new-org --file org.json --dir ./profiles --certPass-file pass.txt",
+				"ucb importCreds --orgId new-org --file org.json --certPass - --yes",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("importCreds")
+			flags.String("file", "", "File written by exportCreds, add certPath and profilePath to an entry to name its files")
+			flags.String("dir", "", "Directory of <label>.p12 and <label>.mobileprovision files for entries without paths")
+			flags.String("certPass", "", "Password of the certificates, - reads it from stdin")
+			flags.String("certPass-file", "", "File to read the certificate password from, - reads it from stdin")
+			flags.Bool("yes", false, "Upload the credentials without asking for confirmation")
+			flags.Bool("strict", false, "Fail instead of warning when a certificate or profile is expiring")
+			flags.String("expiry-window", "30d", "Warn when a certificate or profile expires within this window")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey   string `survey:"apiKey" global:"true"`
+				OrgId    string `survey:"orgId" global:"true"`
+				File     string `survey:"file" type:"filePath"`
+				CertPass string `survey:"certPass" type:"password"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			dir := normalizePath(flags["dir"])
+			if dir != "" {
+				if err := dirExists(dir); err != nil {
+					return err
+				}
+			}
+
+			export, err := readExport(results.File)
+			if err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			plan := importPlan(results.File, dir, export)
+
+			uploads := 0
+			for _, item := range plan {
+				if item.Skip == "" {
+					uploads++
+				}
+			}
+
+			if err := prettyPrint(progress(flags), outputTable, "", plan); err != nil {
+				return err
+			}
+			if len(export.Android) > 0 {
+				fmt.Fprintf(progress(flags), "skipping %d android credentials, keystores aren't in the export so upload them with uploadAndroidCred\n", len(export.Android))
+			}
+			if uploads == 0 {
+				return validationErrorf("no credentials to import from %s", results.File)
+			}
+
+			ok, err := confirm(flags, fmt.Sprintf("Upload %d credentials to %s?", uploads, results.OrgId))
+			if err != nil || !ok {
+				return err
+			}
+
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			imported, importErr := importCreds(ctx, flags, credsService, plan, results.CertPass)
+			if importErr == cloudbuild.ErrDryRun {
+				return importErr
+			}
+
+			if err := prettyPrint(stdout(flags), flags["output"], flags["fields"], imported); err != nil {
+				return err
+			}
+			return importErr
+		},
+	},
+
+	"renameCred": {
+		CommandMeta{
+			Name:    "renameCred",
+			Summary: "Change the Label of an IOS Credential",
+			Examples: []string{
+				"ucb renameCred --certId 0a1b2c3d-4e5f-6789-abcd-ef0123456789 --label release",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("renameCred")
+			flags.String("certId", "", "Certificate Id")
+			flags.String("label", "", "New label, must not be used by another credential")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey string `survey:"apiKey" global:"true"`
+				OrgId  string `survey:"orgId" global:"true"`
+				CertId string `survey:"certId" type:"certId"`
+				Label  string `survey:"label"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := populateArgs(ctx, flags, &results, credsService); err != nil {
+				return err
+			}
+
+			cred, err := credsService.GetIOSContext(ctx, results.CertId)
+			if err != nil {
+				return err
+			}
+
+			creds, err := credsService.GetAllIOSContext(ctx)
+			if err != nil {
+				return err
+			}
+
+			labels := make(map[string]string, len(creds))
+			for _, existing := range creds {
+				labels[existing.Id] = existing.Label
+			}
+
+			if err := checkNewLabel(cred.Id, results.Label, labels); err != nil {
+				return err
+			}
+
+			if cred.Label != results.Label {
+				if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+					return err
+				}
+
+				if cred, err = credsService.RenameIOSContext(ctx, cred.Id, results.Label); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintf(progress(flags), "credential %s is already labelled %q\n", cred.Id, cred.Label)
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], cred)
+		},
+	},
+
+	"deleteCred": {
+		CommandMeta{
+			Name:    "deleteCred",
+			Summary: "Delete a IOS Credential",
+			Examples: []string{
+				"ucb deleteCred --credId 0a1b2c3d-4e5f-6789-abcd-ef0123456789",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("deleteCred")
+			flags.String("credId", "", "Credential Id")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey string `survey:"apiKey" global:"true"`
+				OrgId  string `survey:"orgId" global:"true"`
+				CredId string `survey:"credId" type:"certId"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := populateArgs(ctx, flags, &results, credsService); err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			resp, err := credsService.DeleteIOSContext(ctx, results.CredId)
+			if err != nil {
+				return err
+			}
+
+			if flags["output"] != "" {
+				return prettyPrint(stdout(flags), flags["output"], flags["fields"], credDeletion{results.CredId, true})
+			}
+
+			fmt.Fprintln(stdout(flags), resp.Status)
+
+			return nil
+		},
+	},
+
+	"deleteCredsMatching": {
+		CommandMeta{
+			Name:        "deleteCredsMatching",
+			Summary:     "Delete IOS Credentials With Matching Labels",
+			Description: "The matching credentials are listed and confirmed before any are deleted, --yes skips the confirmation.",
+			Examples: []string{
+				"ucb deleteCredsMatching --pattern '^old-'",
+				"ucb deleteCredsMatching --pattern 2018 --yes",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("deleteCredsMatching")
+			flags.String("pattern", "", "Regular expression matched against credential labels")
+			flags.Bool("yes", false, "Delete without asking for confirmation")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey  string `survey:"apiKey" global:"true"`
+				OrgId   string `survey:"orgId" global:"true"`
+				Pattern string `survey:"pattern"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := populateArgs(ctx, flags, &results, credsService); err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			pattern, err := regexp.Compile(results.Pattern)
+			if err != nil {
+				return validationErrorf("--pattern: %v", err)
+			}
+
+			creds, err := credsService.GetAllIOSContext(ctx)
+			if err != nil {
+				return err
+			}
+
+			var matches []responses.IOSCred
+			for _, cred := range creds {
+				if pattern.MatchString(cred.Label) {
+					matches = append(matches, cred)
+				}
+			}
+
+			if len(matches) == 0 {
+				fmt.Fprintln(stdout(flags), "no credentials match", results.Pattern)
+				return nil
+			}
+
+			for _, cred := range matches {
+				fmt.Fprintf(stdout(flags), "%s (%s)\n", cred.Label, cred.Id)
+			}
+
+			ok, err := confirm(flags, fmt.Sprintf("Delete these %d credentials?", len(matches)))
+			if err != nil || !ok {
+				return err
+			}
+
+			var failed []string
+			for _, cred := range matches {
+				_, err := credsService.DeleteIOSContext(ctx, cred.Id)
+				if err == cloudbuild.ErrDryRun {
+					continue
+				} else if err != nil {
+					failed = append(failed, fmt.Sprintf("%s (%s): %v", cred.Label, cred.Id, err))
+				}
+			}
+
+			if boolFlag(flags, "dry-run") {
+				return cloudbuild.ErrDryRun
+			}
+
+			fmt.Fprintf(stdout(flags), "deleted %d of %d credentials\n", len(matches)-len(failed), len(matches))
+
+			if len(failed) > 0 {
+				return fmt.Errorf("failed to delete %d credentials:\n  %s", len(failed), strings.Join(failed, "\n  "))
+			}
+			return nil
+		},
+	},
+
+	"getAndroidCred": {
+		CommandMeta{
+			Name:    "getAndroidCred",
+			Summary: "Get Android Credential Details",
+			Examples: []string{
+				"ucb getAndroidCred --credId 0a1b2c3d-4e5f-6789-abcd-ef0123456789",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("getAndroidCred")
+			flags.String("credId", "", "Credential Id")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey string `survey:"apiKey" global:"true"`
+				OrgId  string `survey:"orgId" global:"true"`
+				CredId string `survey:"credId" type:"certId" platform:"android"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := populateArgs(ctx, flags, &results, credsService); err != nil {
+				return err
+			}
+
+			cred, err := credsService.GetAndroidContext(ctx, results.CredId)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], cred)
+		},
+	},
+
+	"listAndroidCreds": {
+		CommandMeta{
+			Name:    "listAndroidCreds",
+			Summary: "List all Android Credentials",
+			Examples: []string{
+				"ucb listAndroidCreds --output table",
+				"ucb listAndroidCreds --orgId studio-a --orgId studio-b",
+			},
+		},
+		func() *flag.FlagSet {
+			return CreateFlagSet("listAndroidCreds")
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			if orgs := orgIds(flags); len(orgs) > 1 {
+				return listOrgs(ctx, flags, flags["output"], orgs, androidCredListing)
+			}
+
+			results := struct {
+				ApiKey string `survey:"apiKey" global:"true"`
+				OrgId  string `survey:"orgId" global:"true"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			creds, err := androidCredListing(ctx, flags, results.ApiKey, results.OrgId)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], creds)
+		},
+	},
+
+	"updateAndroidCred": {
+		CommandMeta{
+			Name:    "updateAndroidCred",
+			Summary: "Update a Android Credential",
+			Examples: []string{
+				"ucb updateAndroidCred --credId 0a1b2c3d-4e5f-6789-abcd-ef0123456789 --label release --keystorePath release.keystore --keyAlias upload",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("updateAndroidCred")
+			flags.String("credId", "", "Credential Id")
+			flags.String("label", "", "Label")
+			flags.String("keystorePath", "", "Keystore Path")
+			flags.String("keystorePass", "", "Keystore password")
+			flags.String("keyAlias", "", "Key alias")
+			flags.String("keyPass", "", "Key password")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey       string `survey:"apiKey" global:"true"`
+				OrgId        string `survey:"orgId" global:"true"`
+				CredId       string `survey:"credId" type:"certId" platform:"android"`
+				Label        string `survey:"label"`
+				KeystorePath string `survey:"keystorePath" type:"filePath"`
+				KeystorePass string `survey:"keystorePass" type:"password"`
+				KeyAlias     string `survey:"keyAlias"`
+				KeyPass      string `survey:"keyPass" type:"password"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := populateArgs(ctx, flags, &results, credsService); err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			cred, err := credsService.UpdateAndroidContext(ctx, results.CredId, results.Label, results.KeystorePath, results.KeystorePass, results.KeyAlias, results.KeyPass)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], cred)
+		},
+	},
+
+	"uploadAndroidCred": {
+		CommandMeta{
+			Name:    "uploadAndroidCred",
+			Summary: "Upload a Android Credential",
+			Examples: []string{
+				"ucb uploadAndroidCred --label release --keystorePath release.keystore --keyAlias upload",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("uploadAndroidCred")
+			flags.String("label", "", "Label")
+			flags.String("keystorePath", "", "Keystore Path")
+			flags.String("keystorePass", "", "Keystore password")
+			flags.String("keyAlias", "", "Key alias")
+			flags.String("keyPass", "", "Key password")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey       string `survey:"apiKey" global:"true"`
+				OrgId        string `survey:"orgId" global:"true"`
+				Label        string `survey:"label"`
+				KeystorePath string `survey:"keystorePath" type:"filePath"`
+				KeystorePass string `survey:"keystorePass" type:"password"`
+				KeyAlias     string `survey:"keyAlias"`
+				KeyPass      string `survey:"keyPass" type:"password"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := populateArgs(ctx, flags, &results, credsService); err != nil {
+				return err
+			}
+
+			cred, err := credsService.UploadAndroidContext(ctx, results.Label, results.KeystorePath, results.KeystorePass, results.KeyAlias, results.KeyPass)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], cred)
+		},
+	},
+
+	"renameAndroidCred": {
+		CommandMeta{
+			Name:    "renameAndroidCred",
+			Summary: "Change the Label of an Android Credential",
+			Examples: []string{
+				"ucb renameAndroidCred --credId 0a1b2c3d-4e5f-6789-abcd-ef0123456789 --label release",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("renameAndroidCred")
+			flags.String("credId", "", "Credential Id")
+			flags.String("label", "", "New label, must not be used by another credential")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey string `survey:"apiKey" global:"true"`
+				OrgId  string `survey:"orgId" global:"true"`
+				CredId string `survey:"credId" type:"certId" platform:"android"`
+				Label  string `survey:"label"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := populateArgs(ctx, flags, &results, credsService); err != nil {
+				return err
+			}
+
+			cred, err := credsService.GetAndroidContext(ctx, results.CredId)
+			if err != nil {
+				return err
+			}
+
+			creds, err := credsService.GetAllAndroidContext(ctx)
+			if err != nil {
+				return err
+			}
+
+			labels := make(map[string]string, len(creds))
+			for _, existing := range creds {
+				labels[existing.Id] = existing.Label
+			}
+
+			if err := checkNewLabel(cred.Id, results.Label, labels); err != nil {
+				return err
+			}
+
+			if cred.Label != results.Label {
+				if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+					return err
+				}
+
+				if cred, err = credsService.RenameAndroidContext(ctx, cred.Id, results.Label); err != nil {
+					return err
+				}
+			} else {
+				fmt.Fprintf(progress(flags), "credential %s is already labelled %q\n", cred.Id, cred.Label)
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], cred)
+		},
+	},
+
+	"deleteAndroidCred": {
+		CommandMeta{
+			Name:    "deleteAndroidCred",
+			Summary: "Delete a Android Credential",
+			Examples: []string{
+				"ucb deleteAndroidCred --credId 0a1b2c3d-4e5f-6789-abcd-ef0123456789",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("deleteAndroidCred")
+			flags.String("credId", "", "Credential Id")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey string `survey:"apiKey" global:"true"`
+				OrgId  string `survey:"orgId" global:"true"`
+				CredId string `survey:"credId" type:"certId" platform:"android"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			credsService := cloudbuild.NewCredentialsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := populateArgs(ctx, flags, &results, credsService); err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			resp, err := credsService.DeleteAndroidContext(ctx, results.CredId)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(stdout(flags), resp.Status)
+
+			return nil
+		},
+	},
+
+	"listProjects": {
+		CommandMeta{
+			Name:        "listProjects",
+			Summary:     "List Projects On CloudBuild",
+			Description: "Results can be cached with --cache or the config file, --refresh fetches them again and --no-cache ignores the cache.",
+			Examples: []string{
+				"ucb listProjects --sort id --filter game",
+				"ucb listProjects --cache --cache-ttl 1h",
+				"ucb listProjects --orgId studio-a,studio-b",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("listProjects")
+			flags.Bool("cache", false, "Use cached results if they are younger than --cache-ttl")
+			flags.Bool("no-cache", false, "Ignore the cache even if it is enabled in the config file")
+			flags.Bool("refresh", false, "Fetch the projects and update the cache")
+			flags.String("cache-ttl", defaultCacheTTL, "How long cached results are used for, eg 10m")
+			flags.String("sort", "name", "Sort projects by name or id")
+			flags.String("filter", "", "Only list projects whose name or id contains this text")
+			flags.Int("page-size", cloudbuild.DefaultPageSize, fmt.Sprintf("Results fetched per request, at most %d", cloudbuild.MaxPageSize))
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			format := flags["output"]
+			if format == "" {
+				format = outputTable
+			}
+
+			if orgs := orgIds(flags); len(orgs) > 1 {
+				return listOrgs(ctx, flags, format, orgs, projectListing)
+			}
+
+			results := struct {
+				ApiKey string `survey:"apiKey" global:"true"`
+				OrgId  string `survey:"orgId" global:"true"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			projects, err := projectListing(ctx, flags, results.ApiKey, results.OrgId)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), format, flags["fields"], projects)
+		},
+	},
+
+	"projectsReport": {
+		CommandMeta{
+			Name:    "projectsReport",
+			Summary: "List Projects with How Many of their Build Targets are Enabled",
+			Examples: []string{
+				"ucb projectsReport",
+				"ucb projectsReport --filter game --concurrency 8 --output json",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("projectsReport")
+			flags.Bool("cache", false, "Use cached projects if they are younger than --cache-ttl")
+			flags.Bool("no-cache", false, "Ignore the cache even if it is enabled in the config file")
+			flags.Bool("refresh", false, "Fetch the projects and update the cache")
+			flags.String("cache-ttl", defaultCacheTTL, "How long cached projects are used for, eg 10m")
+			flags.String("sort", "name", "Sort projects by name or id")
+			flags.String("filter", "", "Only report projects whose name or id contains this text")
+			flags.Int("concurrency", defaultConcurrency, "Projects fetched at the same time")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey string `survey:"apiKey" global:"true"`
+				OrgId  string `survey:"orgId" global:"true"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			concurrency, err := concurrencyFlag(flags)
+			if err != nil {
+				return err
+			}
+
+			projects, err := cachedProjects(ctx, flags, results.ApiKey, results.OrgId)
+			if err != nil {
+				return err
+			}
+
+			projects = filterProjects(projects, flags["filter"])
+			if err := sortProjects(projects, flags["sort"]); err != nil {
+				return err
+			}
+
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			rows, batch := projectReport(ctx, targetsService, projects, concurrency)
+
+			format := flags["output"]
+			if format == "" {
+				format = outputTable
+			}
+
+			if err := prettyPrint(stdout(flags), format, flags["fields"], rows); err != nil {
+				return err
+			}
+
+			return projectBatchError(batch)
+		},
+	},
+
+	"listBuildTargets": {
+		CommandMeta{
+			Name:    "listBuildTargets",
+			Summary: "List Build Targets for a Project",
+			Examples: []string{
+				"ucb listBuildTargets --projectId my-game",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("listBuildTargets")
+			flags.String("projectId", "", "Project Id")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey    string `survey:"apiKey" global:"true"`
+				OrgId     string `survey:"orgId" global:"true"`
+				ProjectId string `survey:"projectId"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			targets, err := targetsService.ListAllContext(ctx, results.ProjectId)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], targets)
+		},
+	},
+
+	"listAllBuildTargets": {
+		CommandMeta{
+			Name:    "listAllBuildTargets",
+			Summary: "List Build Targets for Many Projects",
+			Examples: []string{
+				"ucb listAllBuildTargets --concurrency 8",
+				"ucb listAllBuildTargets --projectIds my-game,my-other-game",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("listAllBuildTargets")
+			flags.String("projectIds", "", "Comma separated project ids, defaults to every project")
+			flags.Int("concurrency", defaultConcurrency, "Projects fetched at the same time")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey string `survey:"apiKey" global:"true"`
+				OrgId  string `survey:"orgId" global:"true"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			concurrency, err := concurrencyFlag(flags)
+			if err != nil {
+				return err
+			}
+
+			ids, err := projectIds(ctx, flags, results.ApiKey, results.OrgId)
+			if err != nil {
+				return err
+			}
+
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			batch := cloudbuild.ForEachProject(ctx, ids, concurrency, func(ctx context.Context, projectId string) (interface{}, error) {
+				return targetsService.ListAllContext(ctx, projectId)
+			})
+
+			targets := make([]projectBuildTargets, 0, len(batch))
+			for _, result := range batch {
+				row := projectBuildTargets{ProjectId: result.ProjectId}
+				if result.Err != nil {
+					row.Error = result.Err.Error()
+				} else {
+					row.BuildTargets = result.Value.([]responses.BuildTarget)
+				}
+				targets = append(targets, row)
+			}
+
+			if err := prettyPrint(stdout(flags), flags["output"], flags["fields"], targets); err != nil {
+				return err
+			}
+
+			return projectBatchError(batch)
+		},
+	},
+
+	"getBuildTarget": {
+		CommandMeta{
+			Name:    "getBuildTarget",
+			Summary: "Get Build Target Details",
+			Examples: []string{
+				"ucb getBuildTarget --projectId my-game --buildTargetId ios-release",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("getBuildTarget")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			target, err := targetsService.GetContext(ctx, results.ProjectId, results.BuildTargetId)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], target)
+		},
+	},
+
+	"createBuildTarget": {
+		CommandMeta{
+			Name:        "createBuildTarget",
+			Summary:     "Create a Build Target from a JSON file",
+			Description: "The file is checked against the build target schema before it is sent, --config names it too on this command.",
+			Examples: []string{
+				"ucb createBuildTarget --projectId my-game --file target.json",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("createBuildTarget")
+			flags.Lookup("config").Usage = targetConfigUsage
+			flags.String("projectId", "", "Project Id")
+			flags.String("file", "", "Path to a JSON build target definition")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey    string `survey:"apiKey" global:"true"`
+				OrgId     string `survey:"orgId" global:"true"`
+				ProjectId string `survey:"projectId"`
+				File      string `survey:"file" type:"filePath"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			config, err := readBuildTargetConfig(results.File)
+			if err != nil {
+				return err
+			}
+
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			target, err := targetsService.CreateContext(ctx, results.ProjectId, *config)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], target)
+		},
+	},
+
+	"updateBuildTarget": {
+		CommandMeta{
+			Name:        "updateBuildTarget",
+			Summary:     "Update fields of a Build Target",
+			Description: "Only the fields given as flags or present in --file are changed, the flags take precedence over the file.",
+			Examples: []string{
+				"ucb updateBuildTarget --projectId my-game --buildTargetId ios-release --branch release/1.2",
+				"ucb updateBuildTarget --projectId my-game --buildTargetId ios-release --enabled false",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("updateBuildTarget")
+			flags.Lookup("config").Usage = targetConfigUsage
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.String("file", "", "Path to a JSON build target definition, only the keys present are updated")
+			flags.String("name", "", "Build target name")
+			flags.String("platform", "", "Build target platform")
+			flags.String("branch", "", "Branch to build from")
+			flags.String("unityVersion", "", "Unity version to build with")
+			flags.String("enabled", "", "Enable or disable the build target (true or false)")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			patch, err := buildTargetPatch(flags)
+			if err != nil {
+				return err
+			}
+
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			target, err := targetsService.UpdateContext(ctx, results.ProjectId, results.BuildTargetId, *patch)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], target)
+		},
+	},
+
+	"listBranches": {
+		CommandMeta{
+			Name:    "listBranches",
+			Summary: "List the Branch each Build Target of a Project builds from",
+			Examples: []string{
+				"ucb listBranches --projectId my-game",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("listBranches")
+			flags.String("projectId", "", "Project Id")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey    string `survey:"apiKey" global:"true"`
+				OrgId     string `survey:"orgId" global:"true"`
+				ProjectId string `survey:"projectId"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			targets, err := targetsService.ListAllContext(ctx, results.ProjectId)
+			if err != nil {
+				return err
+			}
+
+			format := flags["output"]
+			if format == "" {
+				format = outputTable
+			}
+
+			return prettyPrint(stdout(flags), format, flags["fields"], branchRows(targets))
+		},
+	},
+
+	"setBranch": {
+		CommandMeta{
+			Name:    "setBranch",
+			Summary: "Set the Branch a Build Target builds from",
+			Examples: []string{
+				"ucb setBranch --projectId my-game --buildTargetId ios-release --branch main",
+				"ucb setBranch --projectId my-game --buildTargetId ios-release --branch release/1.3 --check-branch --yes",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("setBranch")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.String("branch", "", "Branch to build from")
+			flags.Bool("check-branch", false, "Confirm first if no build target of the project builds from the branch yet")
+			flags.Bool("yes", false, "Set an unknown branch without asking for confirmation")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+				Branch        string `survey:"branch"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+
+			if boolFlag(flags, "check-branch") {
+				ok, err := confirmBranch(ctx, flags, targetsService, results.ProjectId, results.Branch)
+				if err != nil || !ok {
+					return err
+				}
+			}
+
+			target, err := targetsService.SetBranchContext(ctx, results.ProjectId, results.BuildTargetId, results.Branch)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], target)
+		},
+	},
+
+	"deleteBuildTarget": {
+		CommandMeta{
+			Name:    "deleteBuildTarget",
+			Summary: "Delete a Build Target",
+			Examples: []string{
+				"ucb deleteBuildTarget --projectId my-game --buildTargetId ios-old --yes",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("deleteBuildTarget")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.Bool("yes", false, "Delete without asking for confirmation")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			ok, err := confirm(flags, fmt.Sprintf("Delete build target %s?", results.BuildTargetId))
+			if err != nil || !ok {
+				return err
+			}
+
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			resp, err := targetsService.DeleteContext(ctx, results.ProjectId, results.BuildTargetId)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(stdout(flags), resp.Status)
+
+			return nil
+		},
+	},
+
+	"clearBuildCache": {
+		CommandMeta{
+			Name:    "clearBuildCache",
+			Summary: "Clear a Build Target's Build Cache",
+			Examples: []string{
+				"ucb clearBuildCache --projectId my-game --buildTargetId ios-release",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("clearBuildCache")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := targetsService.ClearCacheContext(ctx, results.ProjectId, results.BuildTargetId); err != nil {
+				if err == cloudbuild.ErrCacheDisabled {
+					fmt.Fprintf(stdout(flags), "caching is disabled for build target %s, there is nothing to clear\n", results.BuildTargetId)
+					return nil
+				}
+				return err
+			}
+
+			fmt.Fprintf(stdout(flags), "cleared the build cache of %s\n", results.BuildTargetId)
+
+			return nil
+		},
+	},
+
+	"getEnvVars": {
+		CommandMeta{
+			Name:        "getEnvVars",
+			Summary:     "List a Build Target's Environment Variables",
+			Description: "Values are masked unless --show-values is given so they don't end up in logs.",
+			Examples: []string{
+				"ucb getEnvVars --projectId my-game --buildTargetId ios-release",
+				"ucb getEnvVars --projectId my-game --buildTargetId ios-release --show-values --output json",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("getEnvVars")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.Bool("show-values", false, "Print the variables' values instead of masking them")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			vars, err := targetsService.GetEnvVarsContext(ctx, results.ProjectId, results.BuildTargetId)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], printedEnvVars(flags, vars))
+		},
+	},
+
+	"setEnvVar": {
+		CommandMeta{
+			Name:        "setEnvVar",
+			Summary:     "Set a Build Target Environment Variable",
+			Description: "The variable is merged into the build target's existing variables unless --replace is given. Values are masked in the output unless --show-values is given.",
+			Examples: []string{
+				"ucb setEnvVar --projectId my-game --buildTargetId ios-release --key API_HOST --value api.example.com",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("setEnvVar")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.String("key", "", "Variable name")
+			flags.String("value", "", "Variable value, - reads it from stdin")
+			flags.String("value-file", "", "File to read the variable value from, - reads it from stdin")
+			flags.Bool("replace", false, "Replace all existing variables instead of merging")
+			flags.Bool("show-values", false, "Print the variables' values instead of masking them")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+				Key           string `survey:"key"`
+				Value         string `survey:"value" type:"password"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+
+			vars := make(map[string]string)
+			if !boolFlag(flags, "replace") {
+				existing, err := targetsService.GetEnvVarsContext(ctx, results.ProjectId, results.BuildTargetId)
+				if err != nil {
+					return err
+				}
+				vars = existing
+			}
+			vars[results.Key] = results.Value
+
+			updated, err := targetsService.SetEnvVarsContext(ctx, results.ProjectId, results.BuildTargetId, vars)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], printedEnvVars(flags, updated))
+		},
+	},
+
+	"deleteEnvVar": {
+		CommandMeta{
+			Name:    "deleteEnvVar",
+			Summary: "Delete a Build Target Environment Variable",
+			Examples: []string{
+				"ucb deleteEnvVar --projectId my-game --buildTargetId ios-release --key API_HOST",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("deleteEnvVar")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.String("key", "", "Variable name")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+				Key           string `survey:"key"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := targetsService.DeleteEnvVarContext(ctx, results.ProjectId, results.BuildTargetId, results.Key); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(stdout(flags), "deleted %s\n", results.Key)
+
+			return nil
+		},
+	},
+
+	"startBuild": {
+		CommandMeta{
+			Name:        "startBuild",
+			Summary:     "Queue a Build for a Build Target",
+			Description: "With --idempotency-key running the command again returns the build it already started instead of queuing another.",
+			Examples: []string{
+				"ucb startBuild --projectId my-game --buildTargetId ios-release --clean",
+				"ucb startBuild --projectId my-game --buildTargetId ios-release --idempotency-key $CI_PIPELINE_ID",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("startBuild")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.Bool("clean", false, "Force a clean build")
+			flags.String("idempotency-key", "", "Key identifying this start, re-running with it returns the build it started instead of queuing another")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			buildsService := cloudbuild.NewBuildsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			build, err := startBuild(ctx, flags, buildsService, results.ProjectId, results.BuildTargetId)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], build)
+		},
+	},
+
+	"build": {
+		CommandMeta{
+			Name:        "build",
+			Summary:     "Start a Build and Wait for it to Finish",
+			Description: "Status changes are printed to stderr while waiting, the exit code is 5 when the build fails or is canceled. With --download the artifact is saved once the build succeeds.",
+			Examples: []string{
+				"ucb build --projectId my-game --buildTargetId ios-release --download build.ipa",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("build")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.Bool("clean", false, "Force a clean build")
+			flags.String("idempotency-key", "", "Key identifying this start, re-running with it returns the build it started instead of queuing another")
+			flags.String("interval", defaultPollInterval, "Time between status checks, eg 30s")
+			flags.String("timeout", "0", "Give up waiting after this long, eg 1h, 0 waits forever")
+			flags.String("download", "", "Path to download the artifact to once the build succeeds")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			buildsService := cloudbuild.NewBuildsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			queued, err := startBuild(ctx, flags, buildsService, results.ProjectId, results.BuildTargetId)
+			if err != nil {
+				return err
+			}
+
+			build, err := waitForBuild(ctx, flags, buildsService, results.ProjectId, results.BuildTargetId, queued.Number)
+			if build == nil {
+				return err
+			}
+
+			if out := flags["download"]; err == nil && out != "" {
+				bars := newDownloadProgress(flags)
+				path, written, err := downloadArtifact(ctx, buildsService, results.ProjectId, results.BuildTargetId, build.Number, out, bars.track(fmt.Sprintf("#%d", build.Number)))
+				bars.done()
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(progress(flags), "downloaded %d bytes to %s\n", written, path)
+			}
+
+			if printErr := prettyPrint(stdout(flags), flags["output"], flags["fields"], build); printErr != nil {
+				return printErr
+			}
+			return err
+		},
+	},
+
+	"listBuilds": {
+		CommandMeta{
+			Name:    "listBuilds",
+			Summary: "List Builds for a Build Target",
+			Examples: []string{
+				"ucb listBuilds --projectId my-game --buildTargetId ios-release --status failure --limit 5",
+				"ucb listBuilds --projectId my-game --buildTargetId _all --since 7d",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("listBuilds")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id, _all lists builds for every target")
+			flags.String("status", "", "Only list builds with this status (queued, building, success, failure, canceled)")
+			flags.Int("limit", 0, "Maximum number of builds to list")
+			flags.String("since", "", "Only list builds that finished after this, a duration back from now like 7d or a time like 2019-01-31T15:04:05Z")
+			flags.Int("page-size", cloudbuild.DefaultPageSize, fmt.Sprintf("Results fetched per request, at most %d", cloudbuild.MaxPageSize))
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			opts, err := listBuildsOptions(flags)
+			if err != nil {
+				return err
+			}
+
+			buildsService := cloudbuild.NewBuildsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			builds, err := buildsService.ListContext(ctx, results.ProjectId, results.BuildTargetId, opts)
+			if err != nil {
+				return err
+			}
+
+			if format := flags["output"]; format != "" && format != outputTable {
+				return prettyPrint(stdout(flags), format, flags["fields"], builds)
+			}
+
+			return prettyPrint(stdout(flags), outputTable, flags["fields"], buildRows(builds))
+		},
+	},
+
+	"projectBuilds": {
+		CommandMeta{
+			Name:    "projectBuilds",
+			Summary: "List the Latest Build of Every Build Target in a Project",
+			Examples: []string{
+				"ucb projectBuilds --projectId my-game",
+				"ucb projectBuilds --projectId my-game --concurrency 8 --output json",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("projectBuilds")
+			flags.String("projectId", "", "Project Id")
+			flags.Int("concurrency", defaultConcurrency, "Build targets fetched at the same time")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey    string `survey:"apiKey" global:"true"`
+				OrgId     string `survey:"orgId" global:"true"`
+				ProjectId string `survey:"projectId"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			concurrency, err := concurrencyFlag(flags)
+			if err != nil {
+				return err
+			}
+
+			targetsService := cloudbuild.NewBuildTargetsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			targets, err := targetsService.ListAllContext(ctx, results.ProjectId)
+			if err != nil {
+				return err
+			}
+
+			buildsService := cloudbuild.NewBuildsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			rows, err := latestBuilds(ctx, buildsService, results.ProjectId, targets, concurrency)
+			if ctx.Err() != nil {
+				return err
+			}
+
+			format := flags["output"]
+			if format == "" {
+				format = outputTable
+			}
+
+			if printErr := prettyPrint(stdout(flags), format, flags["fields"], rows); printErr != nil {
+				return printErr
+			}
+			return err
+		},
+	},
+
+	"getBuild": {
+		CommandMeta{
+			Name:    "getBuild",
+			Summary: "Get Build Details",
+			Examples: []string{
+				"ucb getBuild --projectId my-game --buildTargetId ios-release --buildNumber 42",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("getBuild")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.String("buildNumber", "", "Build Number")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+				BuildNumber   string `survey:"buildNumber"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			buildNumber, err := parseBuildNumber(results.BuildNumber)
+			if err != nil {
+				return err
+			}
+
+			buildsService := cloudbuild.NewBuildsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			build, err := buildsService.GetContext(ctx, results.ProjectId, results.BuildTargetId, buildNumber)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], build)
+		},
+	},
+
+	"statusBuild": {
+		CommandMeta{
+			Name:    "statusBuild",
+			Summary: "Print just the Status of a Build, the exit code is 0 on success, 5 on failure and 6 while running",
+			Examples: []string{
+				"ucb statusBuild --projectId my-game --buildTargetId ios-release --buildNumber 42",
+				"if ucb statusBuild --target @ios-prod --buildNumber 42 > /dev/null; then echo shipped; fi",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("statusBuild")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.String("buildNumber", "", "Build Number")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+				BuildNumber   string `survey:"buildNumber"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			buildNumber, err := parseBuildNumber(results.BuildNumber)
+			if err != nil {
+				return err
+			}
+
+			buildsService := cloudbuild.NewBuildsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			build, err := buildsService.GetContext(ctx, results.ProjectId, results.BuildTargetId, buildNumber)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(stdout(flags), build.Status)
+
+			switch {
+			case build.Status == responses.BuildStatusSuccess:
+				return nil
+			case build.Status.Finished():
+				return &BuildFailedError{build.Number, string(build.Status)}
+			default:
+				return &BuildRunningError{build.Number, string(build.Status)}
+			}
+		},
+	},
+
+	"waitBuild": {
+		CommandMeta{
+			Name:        "waitBuild",
+			Summary:     "Wait for a Build to Finish",
+			Description: "Status changes are printed to stderr while waiting, the exit code is 5 when the build fails or is canceled.",
+			Examples: []string{
+				"ucb waitBuild --projectId my-game --buildTargetId ios-release --buildNumber 42 --interval 1m --timeout 2h",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("waitBuild")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.String("buildNumber", "", "Build Number")
+			flags.String("interval", defaultPollInterval, "Time between status checks, eg 30s")
+			flags.String("timeout", "0", "Give up waiting after this long, eg 1h, 0 waits forever")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+				BuildNumber   string `survey:"buildNumber"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			buildNumber, err := parseBuildNumber(results.BuildNumber)
+			if err != nil {
+				return err
+			}
+
+			buildsService := cloudbuild.NewBuildsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			build, err := waitForBuild(ctx, flags, buildsService, results.ProjectId, results.BuildTargetId, buildNumber)
+			if build == nil {
+				return err
+			}
+
+			if printErr := prettyPrint(stdout(flags), flags["output"], flags["fields"], build); printErr != nil {
+				return printErr
+			}
+			return err
+		},
+	},
+
+	"buildLog": {
+		CommandMeta{
+			Name:        "buildLog",
+			Summary:     "Print a Build's Log",
+			Description: "The log is written to stdout unless --out names a file.",
+			Examples: []string{
+				"ucb buildLog --projectId my-game --buildTargetId ios-release --buildNumber 42 --out build.log",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("buildLog")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.String("buildNumber", "", "Build Number")
+			flags.String("out", "", "Path to write the log to instead of stdout")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+				BuildNumber   string `survey:"buildNumber"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			buildNumber, err := parseBuildNumber(results.BuildNumber)
+			if err != nil {
+				return err
+			}
+
+			buildsService := cloudbuild.NewBuildsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			buildLog, err := buildsService.GetLogContext(ctx, results.ProjectId, results.BuildTargetId, buildNumber)
+			if err != nil {
+				return err
+			}
+			defer buildLog.Close()
+
+			w := stdout(flags)
+			if out := flags["out"]; out != "" {
+				f, err := os.Create(normalizePath(out))
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+
+			_, err = io.Copy(w, buildLog)
+			return err
+		},
+	},
+
+	"cancelBuild": {
+		CommandMeta{
+			Name:    "cancelBuild",
+			Summary: "Cancel a Queued or Running Build",
+			Examples: []string{
+				"ucb cancelBuild --projectId my-game --buildTargetId ios-release --buildNumber 42",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("cancelBuild")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.String("buildNumber", "", "Build Number")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+				BuildNumber   string `survey:"buildNumber"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			buildNumber, err := parseBuildNumber(results.BuildNumber)
+			if err != nil {
+				return err
+			}
+
+			buildsService := cloudbuild.NewBuildsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := buildsService.CancelContext(ctx, results.ProjectId, results.BuildTargetId, buildNumber); err != nil {
+				if err == cloudbuild.ErrBuildFinished {
+					return fmt.Errorf("build %d is already complete and can't be cancelled", buildNumber)
+				}
+				return err
+			}
+
+			fmt.Fprintf(stdout(flags), "cancelled build %d\n", buildNumber)
+
+			return nil
+		},
+	},
+
+	"downloadBuild": {
+		CommandMeta{
+			Name:        "downloadBuild",
+			Summary:     "Download a Build's Artifact",
+			Description: "With --buildNumbers several artifacts are downloaded at once into the --out directory.",
+			Examples: []string{
+				"ucb downloadBuild --projectId my-game --buildTargetId ios-release --buildNumber 42 --out build.ipa",
+				"ucb downloadBuild --projectId my-game --buildTargetId ios-release --buildNumbers 40,41,42 --out builds/ --concurrency 2",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("downloadBuild")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.String("buildNumber", "", "Build Number")
+			flags.String("buildNumbers", "", "Comma separated build numbers to download, --out is then a directory")
+			flags.String("out", "", "Path to write the artifact to, or a directory to name it <buildTargetId>-<buildNumber> in")
+			flags.Int("concurrency", defaultConcurrency, "Artifacts downloaded at the same time with --buildNumbers")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+				Out           string `survey:"out"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if list := flags["buildNumbers"]; list != "" {
+				concurrency, err := concurrencyFlag(flags)
+				if err != nil {
+					return err
+				}
+
+				var buildNumbers []int
+				for _, item := range splitList(list) {
+					buildNumber, err := parseBuildNumber(item)
+					if err != nil {
+						return err
+					}
+					buildNumbers = append(buildNumbers, buildNumber)
+				}
+
+				if err := populateArgs(ctx, flags, &results, nil); err != nil {
+					return err
+				}
+
+				buildsService := cloudbuild.NewBuildsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+				downloads, err := downloadArtifacts(ctx, flags, buildsService, results.ProjectId, results.BuildTargetId, buildNumbers, normalizePath(results.Out), concurrency)
+				if downloads == nil {
+					return err
+				}
+
+				if printErr := prettyPrint(stdout(flags), flags["output"], flags["fields"], downloads); printErr != nil {
+					return printErr
+				}
+				return err
+			}
+
+			number := struct {
+				BuildNumber string `survey:"buildNumber"`
+			}{}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &number, nil); err != nil {
+				return err
+			}
+
+			buildNumber, err := parseBuildNumber(number.BuildNumber)
+			if err != nil {
+				return err
+			}
+
+			buildsService := cloudbuild.NewBuildsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			bars := newDownloadProgress(flags)
+			path, written, err := downloadArtifact(ctx, buildsService, results.ProjectId, results.BuildTargetId, buildNumber, normalizePath(results.Out), bars.track(fmt.Sprintf("#%d", buildNumber)))
+			bars.done()
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(stdout(flags), "downloaded %d bytes to %s\n", written, path)
+
+			return nil
+		},
+	},
+
+	"shareBuild": {
+		CommandMeta{
+			Name:    "shareBuild",
+			Summary: "Create a Share Link for a Build",
+			Examples: []string{
+				"ucb shareBuild --projectId my-game --buildTargetId ios-release --buildNumber 42",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("shareBuild")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.String("buildNumber", "", "Build Number")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+				BuildNumber   string `survey:"buildNumber"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			buildNumber, err := parseBuildNumber(results.BuildNumber)
+			if err != nil {
+				return err
+			}
+
+			buildsService := cloudbuild.NewBuildsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			shareUrl, err := buildsService.CreateShareLinkContext(ctx, results.ProjectId, results.BuildTargetId, buildNumber)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(stdout(flags), shareUrl)
+
+			return nil
+		},
+	},
+
+	"revokeShareLink": {
+		CommandMeta{
+			Name:    "revokeShareLink",
+			Summary: "Revoke a Build's Share Link",
+			Examples: []string{
+				"ucb revokeShareLink --projectId my-game --buildTargetId ios-release --buildNumber 42",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("revokeShareLink")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.String("buildNumber", "", "Build Number")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey        string `survey:"apiKey" global:"true"`
+				OrgId         string `survey:"orgId" global:"true"`
+				ProjectId     string `survey:"projectId"`
+				BuildTargetId string `survey:"buildTargetId"`
+				BuildNumber   string `survey:"buildNumber"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			buildNumber, err := parseBuildNumber(results.BuildNumber)
+			if err != nil {
+				return err
+			}
+
+			buildsService := cloudbuild.NewBuildsService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			if err := buildsService.RevokeShareLinkContext(ctx, results.ProjectId, results.BuildTargetId, buildNumber); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(stdout(flags), "revoked share link for build %d\n", buildNumber)
+
+			return nil
+		},
+	},
+
+	"listHooks": {
+		CommandMeta{
+			Name:    "listHooks",
+			Summary: "List a Project's Webhooks",
+			Examples: []string{
+				"ucb listHooks --projectId my-game",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("listHooks")
+			flags.String("projectId", "", "Project Id")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey    string `survey:"apiKey" global:"true"`
+				OrgId     string `survey:"orgId" global:"true"`
+				ProjectId string `survey:"projectId"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			hooksService := cloudbuild.NewWebhooksService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			hooks, err := hooksService.ListContext(ctx, results.ProjectId)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], hooks)
+		},
+	},
+
+	"createHook": {
+		CommandMeta{
+			Name:    "createHook",
+			Summary: "Add a Webhook to a Project",
+			Examples: []string{
+				"ucb createHook --projectId my-game --url https://hooks.example.com/build --events ProjectBuildSuccess,ProjectBuildFailure",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("createHook")
+			flags.String("projectId", "", "Project Id")
+			flags.String("url", "", "Https url the hook posts to")
+			flags.String("events", "", "Comma separated events to send, defaults to every build event")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey    string `survey:"apiKey" global:"true"`
+				OrgId     string `survey:"orgId" global:"true"`
+				ProjectId string `survey:"projectId"`
+				Url       string `survey:"url"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			events, err := parseHookEvents(flags["events"])
+			if err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			hooksService := cloudbuild.NewWebhooksService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			hook, err := hooksService.CreateContext(ctx, results.ProjectId, results.Url, events)
+			if err != nil {
+				return err
+			}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], hook)
+		},
+	},
+
+	"deleteHook": {
+		CommandMeta{
+			Name:    "deleteHook",
+			Summary: "Remove a Webhook from a Project",
+			Examples: []string{
+				"ucb deleteHook --projectId my-game --hookId 12",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("deleteHook")
+			flags.String("projectId", "", "Project Id")
+			flags.String("hookId", "", "Webhook Id")
+			flags.Bool("yes", false, "Delete without asking for confirmation")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey    string `survey:"apiKey" global:"true"`
+				OrgId     string `survey:"orgId" global:"true"`
+				ProjectId string `survey:"projectId"`
+				HookId    string `survey:"hookId"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			if err := populateArgs(ctx, flags, &results, nil); err != nil {
+				return err
+			}
+
+			if err := guardProtectedOrg(ctx, flags, results.ApiKey, results.OrgId); err != nil {
+				return err
+			}
+
+			ok, err := confirm(flags, fmt.Sprintf("Delete webhook %s?", results.HookId))
+			if err != nil || !ok {
+				return err
+			}
+
+			hooksService := cloudbuild.NewWebhooksService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			resp, err := hooksService.DeleteContext(ctx, results.ProjectId, results.HookId)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(stdout(flags), resp.Status)
+
+			return nil
+		},
+	},
+
+	"version": {
+		CommandMeta{
+			Name:    "version",
+			Summary: "Print the Version of this Tool",
+			Examples: []string{
+				"ucb version --output json",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("version")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			info := version.Get()
+
+			if flags["output"] != "" {
+				return prettyPrint(stdout(flags), flags["output"], flags["fields"], info)
+			}
+
+			fmt.Fprintf(stdout(flags), "ucb %s (commit: %s, built: %s, %s)\n", info.Version, orUnknown(info.Commit), orUnknown(info.Date), info.GoVersion)
+
+			return nil
+		},
+	},
+
+	"flushQueue": {
+		CommandMeta{
+			Name:        "flushQueue",
+			Summary:     "Send the Changes Queued by --offline-queue",
+			Description: "Requests are sent oldest first with the current profile's api key. The first one that still can't reach the api stops the flush and it and the rest stay queued.",
+			Examples: []string{
+				"ucb flushQueue",
+				"ucb flushQueue --list",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("flushQueue")
+			flags.Bool("list", false, "List the queued requests without sending them")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			list := boolFlag(flags, "list")
+
+			// queued requests are sent as the current profile, only listing them doesn't need a key
+			results := struct {
+				ApiKey string `survey:"apiKey" global:"true"`
+			}{}
+			if !list {
+				if err := populateGlobalArgs(flags, &results); err != nil {
+					return err
+				}
+
+				if err := populateArgs(ctx, flags, &results, nil); err != nil {
+					return err
+				}
+			}
+
+			replays, err := flushQueue(ctx, flags, results.ApiKey, list)
+
+			format := flags["output"]
+			if format == "" {
+				format = outputTable
+			}
+
+			if len(replays) > 0 {
+				if err := prettyPrint(stdout(flags), format, flags["fields"], replays); err != nil {
+					return err
+				}
+			} else if err == nil {
+				fmt.Fprintln(progress(flags), "nothing is queued")
+			}
+			return err
+		},
+	},
+
+	"validateConfig": {
+		CommandMeta{
+			Name:        "validateConfig",
+			Summary:     "Check the Config File's Settings and Credentials",
+			Description: "The config file is checked against its schema, --live also tries each profile's api key. The exit code is 4 when a check fails.",
+			Examples: []string{
+				"ucb validateConfig",
+				"ucb validateConfig --live",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("validateConfig")
+			flags.Bool("live", false, "Also try each profile's credentials against the api")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			checks, err := checkConfig(ctx, flags, boolFlag(flags, "live"))
+			if err != nil {
+				return err
+			}
+
+			format := flags["output"]
+			if format == "" {
+				format = outputTable
+			}
+
+			if err := prettyPrint(stdout(flags), format, flags["fields"], checks); err != nil {
+				return err
+			}
+
+			if failed := failedChecks(checks); failed > 0 {
+				return validationErrorf("%d of %d checks failed", failed, len(checks))
+			}
+			return nil
+		},
+	},
+
+	"whoami": {
+		CommandMeta{
+			Name:    "whoami",
+			Summary: "Check the Api Key and Org Id Work",
+			Examples: []string{
+				"ucb whoami",
+				"ucb whoami --profile work",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("whoami")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey string `survey:"apiKey" global:"true"`
+				OrgId  string `survey:"orgId" global:"true"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			orgService := cloudbuild.NewOrgService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			user, err := orgService.CurrentUserContext(ctx)
+			if err != nil {
+				return err
+			}
+
+			orgName, err := orgService.NameContext(ctx)
+			if err != nil {
+				return err
+			}
+
+			identity := struct {
+				Name    string `json:"name"`
+				Email   string `json:"email"`
+				OrgId   string `json:"orgId"`
+				OrgName string `json:"orgName"`
+			}{user.Name, user.Email, results.OrgId, orgName}
+
+			return prettyPrint(stdout(flags), flags["output"], flags["fields"], identity)
+		},
+	},
+
+	"auditLog": {
+		CommandMeta{
+			Name:    "auditLog",
+			Summary: "List the Organization's Audit Log",
+			Examples: []string{
+				"ucb auditLog --since 2019-01-01 --actor jane@example.com",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("auditLog")
+			flags.String("since", "", "Only list entries from this time on, eg 2019-01-31 or 2019-01-31T15:04:05Z")
+			flags.String("until", "", "Only list entries before this time")
+			flags.String("actor", "", "Only list entries made by this user")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			results := struct {
+				ApiKey string `survey:"apiKey" global:"true"`
+				OrgId  string `survey:"orgId" global:"true"`
+			}{}
+
+			if err := populateGlobalArgs(flags, &results); err != nil {
+				return err
+			}
+
+			opts, err := auditLogOptions(flags)
+			if err != nil {
+				return err
+			}
+
+			orgService := cloudbuild.NewOrgService(results.ApiKey, results.OrgId, serviceOptions(flags)...)
+			entries, err := orgService.GetAuditLogContext(ctx, opts)
+			if err != nil {
+				return err
+			}
+
+			format := flags["output"]
+			if format == "" {
+				format = outputTable
+			}
+
+			return prettyPrint(stdout(flags), format, flags["fields"], entries)
+		},
+	},
+
+	"favorite": {
+		CommandMeta{
+			Name:        "favorite",
+			Summary:     "List, Save or Delete favourite Build Targets used with --target @name",
+			Description: "With no flags the saved favourites are listed, --name saves one and --delete removes one.",
+			Examples: []string{
+				"ucb favorite",
+				"ucb favorite --name ios-prod --projectId my-game --buildTargetId ios-release",
+				"ucb getBuildTarget --target @ios-prod",
+				"ucb favorite --delete ios-prod",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := CreateFlagSet("favorite")
+			flags.String("name", "", "Name to save the project and build target under")
+			flags.String("projectId", "", "Project Id")
+			flags.String("buildTargetId", "", "Build Target Id")
+			flags.String("delete", "", "Name of a favourite to delete")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			if name := flags["delete"]; name != "" {
+				if err := settings.DeleteAlias(name); err != nil {
+					return err
+				}
+				fmt.Fprintf(stdout(flags), "deleted favourite %s\n", strings.TrimPrefix(name, "@"))
+				return nil
+			}
+
+			if name := flags["name"]; name != "" {
+				var missing []string
+				for _, required := range []string{"projectId", "buildTargetId"} {
+					if flags[required] == "" {
+						missing = append(missing, required)
+					}
+				}
+				if len(missing) > 0 {
+					return validationErrorf("saving a favourite needs --%s", strings.Join(missing, ", --"))
+				}
+
+				alias := settings.Alias{ProjectId: flags["projectId"], BuildTargetId: flags["buildTargetId"]}
+				if err := settings.SetAlias(name, alias); err != nil {
+					return err
+				}
+				fmt.Fprintf(stdout(flags), "saved @%s\n", strings.TrimPrefix(name, "@"))
+				return nil
+			}
+
+			aliases, err := settings.GetAliases()
+			if err != nil {
+				return err
+			}
+
+			format := flags["output"]
+			if format == "" {
+				format = outputTable
+			}
+
+			return prettyPrint(stdout(flags), format, flags["fields"], aliasRows(aliases))
+		},
+	},
+
+	"config": {
+		CommandMeta{
+			Name:        "config",
+			Summary:     "Edit config file",
+			Description: "The config file is opened in $VISUAL or $EDITOR and created first if it doesn't exist, --setup prompts for the default api key and org id instead.",
+			Examples: []string{
+				"ucb config",
+				"ucb config --setup --profile work",
+			},
+		},
+		func() *flag.FlagSet {
+			flags := flag.NewFlagSet("config", flag.ContinueOnError)
+			flags.String("apiKey", "", "Default Api Key")
+			flags.String("orgId", "", "Default Organization Id")
+			flags.String("profile", "", "Profile to set the api key and org id for")
+			flags.String("config", "", "Path of the config file to edit, defaults to $UCB_CONFIG or ~/.cloudbuild")
+			flags.Bool("setup", false, "Set the default api key and org id instead of opening an editor")
+			return flags
+		}(),
+		func(ctx context.Context, flags map[string]string) error {
+			if boolFlag(flags, "setup") {
+				return setupConfig(flags)
+			}
+
+			dotFilePath, err := settings.GetFilePath()
+			if err != nil {
+				return err
+			}
+
+			if _, err := os.Stat(dotFilePath); os.IsNotExist(err) {
+				if err := settings.CreateDotFile(dotFilePath); err != nil {
+					return err
+				}
+			}
+
+			cmd, err := editorCommand(dotFilePath)
+			if err != nil {
+				return err
+			}
 
 			cmd.Stdin = os.Stdin
 			cmd.Stdout = os.Stdout
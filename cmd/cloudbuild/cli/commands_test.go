@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testCredId = "0a1b2c3d-4e5f-6789-abcd-ef0123456789"
+
+func TestDeleteCredFlagSkipsPrompt(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("DELETE /api/v1/orgs/example/credentials/signing/ios/"+testCredId, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// without the flag the id would have to be prompted for, which fails when running non-interactively
+	err := runCommand("deleteCred", commandFlags(t, api, nil))
+	if err == nil || !strings.Contains(err.Error(), "credId") {
+		t.Fatalf("got %v, want credId to be missing", err)
+	}
+
+	flags, err := ParseFlags(Commands["deleteCred"].Flags, []string{
+		"--apiKey", testApiKey, "--orgId", testOrgId, "--api-url", api.URL + "/", "--no-interactive", "--quiet",
+		"--credId", testCredId,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runCommand("deleteCred", flags); err != nil {
+		t.Fatal(err)
+	}
+	if !api.received("DELETE /api/v1/orgs/example/credentials/signing/ios/" + testCredId) {
+		t.Errorf("the credential given by --credId wasn't deleted, requests: %v", api.requests)
+	}
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+const programName = "ucb"
+
+var completionFlags = flag.NewFlagSet("completion", flag.ContinueOnError)
+
+// registered in init as the scripts are generated from Commands
+func init() {
+	registerCommand(Command{
+		CommandMeta{
+			Name:        "completion",
+			Summary:     "Print a shell completion script (bash, zsh or fish)",
+			Description: "The script completes command names and each command's flags, install it where your shell loads completions from.",
+			Examples: []string{
+				"ucb completion bash > /etc/bash_completion.d/ucb",
+				"ucb completion zsh > \"${fpath[1]}/_ucb\"",
+				"ucb completion fish > ~/.config/fish/completions/ucb.fish",
+			},
+		},
+		completionFlags,
+		func(ctx context.Context, flags map[string]string) error {
+			script, err := completionScript(completionFlags.Arg(0))
+			if err != nil {
+				return err
+			}
+
+			fmt.Print(script)
+
+			return nil
+		},
+	})
+}
+
+func completionScript(shell string) (string, error) {
+	switch shell {
+	case "bash":
+		return bashCompletion(), nil
+	case "zsh":
+		return zshCompletion(), nil
+	case "fish":
+		return fishCompletion(), nil
+	case "":
+		return "", validationErrorf("usage: %s completion <bash|zsh|fish>", programName)
+	default:
+		return "", validationErrorf("unsupported shell %q, must be one of: bash, zsh, fish", shell)
+	}
+}
+
+func bashCompletion() string {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "_%s() {\n", programName)
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    if [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(&b, "        COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") )\n", strings.Join(CommandOrder[:], " "))
+	b.WriteString("        return\n")
+	b.WriteString("    fi\n")
+	b.WriteString("    case \"${COMP_WORDS[1]}\" in\n")
+
+	for _, key := range CommandOrder {
+		names := make([]string, 0)
+		for _, f := range Commands[key].Meta.Flags {
+			names = append(names, "--"+f.Name)
+		}
+		fmt.Fprintf(&b, "        %s) COMPREPLY=( $(compgen -W \"%s\" -- \"$cur\") ) ;;\n", key, strings.Join(names, " "))
+	}
+
+	b.WriteString("    esac\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "complete -o default -F _%s %s\n", programName, programName)
+
+	return b.String()
+}
+
+var zshEscaper = strings.NewReplacer("'", "'\\''", "[", "\\[", "]", "\\]", ":", "\\:")
+
+func zshCompletion() string {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "#compdef %s\n\n", programName)
+	fmt.Fprintf(&b, "_%s() {\n", programName)
+	b.WriteString("    local -a commands\n")
+	b.WriteString("    commands=(\n")
+	for _, key := range CommandOrder {
+		fmt.Fprintf(&b, "        '%s:%s'\n", key, zshEscaper.Replace(Commands[key].Meta.Summary))
+	}
+	b.WriteString("    )\n\n")
+	b.WriteString("    if (( CURRENT == 2 )); then\n")
+	b.WriteString("        _describe 'command' commands\n")
+	b.WriteString("        return\n")
+	b.WriteString("    fi\n\n")
+	b.WriteString("    case $words[2] in\n")
+
+	for _, key := range CommandOrder {
+		args := make([]string, 0)
+		for _, f := range Commands[key].Meta.Flags {
+			arg := fmt.Sprintf("'--%s[%s]", f.Name, zshEscaper.Replace(f.Usage))
+			if !f.Bool {
+				arg += ":value:_files"
+			}
+			args = append(args, arg+"'")
+		}
+
+		if len(args) == 0 {
+			fmt.Fprintf(&b, "        %s) ;;\n", key)
+		} else {
+			fmt.Fprintf(&b, "        %s) _arguments %s ;;\n", key, strings.Join(args, " "))
+		}
+	}
+
+	b.WriteString("    esac\n")
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "compdef _%s %s\n", programName, programName)
+
+	return b.String()
+}
+
+var fishEscaper = strings.NewReplacer("'", "\\'")
+
+func fishCompletion() string {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, "complete -c %s -f\n", programName)
+
+	for _, key := range CommandOrder {
+		fmt.Fprintf(&b, "complete -c %s -n '__fish_use_subcommand' -a %s -d '%s'\n", programName, key, fishEscaper.Replace(Commands[key].Meta.Summary))
+	}
+
+	for _, key := range CommandOrder {
+		for _, f := range Commands[key].Meta.Flags {
+			line := fmt.Sprintf("complete -c %s -n '__fish_seen_subcommand_from %s' -l %s -d '%s'", programName, key, f.Name, fishEscaper.Replace(f.Usage))
+			if !f.Bool {
+				line += " -r -F"
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	return b.String()
+}
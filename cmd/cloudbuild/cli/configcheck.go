@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/cmcpasserby/ucb/cmd/cloudbuild/settings"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
+	"sort"
+	"strings"
+)
+
+const (
+	checkPass = "pass"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+type configCheck struct {
+	Check  string `json:"check"`
+	Result string `json:"result"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func passCheck(check, detail string) configCheck {
+	return configCheck{check, checkPass, detail}
+}
+
+func failCheck(check string, err error) configCheck {
+	return configCheck{check, checkFail, err.Error()}
+}
+
+func checkResult(check string, err error, detail string) configCheck {
+	if err != nil {
+		return failCheck(check, err)
+	}
+	return passCheck(check, detail)
+}
+
+// checkConfig validates the config file against its schema and the values in it, with live set the credentials of
+// every profile are also tried against the api
+func checkConfig(ctx context.Context, flags map[string]string, live bool) ([]configCheck, error) {
+	dotPath, err := settings.GetFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := settings.ParseDotFile()
+	if err != nil {
+		return []configCheck{failCheck("config file", err)}, nil
+	}
+	checks := []configCheck{passCheck("config file", dotPath)}
+
+	if data.Defaults.Output != "" {
+		checks = append(checks, checkResult("defaults.output", validateOutputFormat(data.Defaults.Output), data.Defaults.Output))
+	}
+
+	urls := regionURLs()
+	regions := make([]string, 0, len(data.Regions))
+	for name := range data.Regions {
+		regions = append(regions, name)
+	}
+	sort.Strings(regions)
+	for _, name := range regions {
+		checks = append(checks, checkResult("regions."+name, validateAPIURL(data.Regions[name]), data.Regions[name]))
+	}
+
+	checkRegion := func(check, region string) {
+		if region == "" {
+			return
+		}
+		if _, ok := urls[strings.ToLower(region)]; !ok {
+			checks = append(checks, configCheck{check, checkFail, fmt.Sprintf("unknown region %q", region)})
+		} else {
+			checks = append(checks, passCheck(check, region))
+		}
+	}
+	checkRegion("region", data.Region)
+
+	aliasNames := make([]string, 0, len(data.Aliases))
+	for name := range data.Aliases {
+		aliasNames = append(aliasNames, name)
+	}
+	sort.Strings(aliasNames)
+	for _, name := range aliasNames {
+		alias := data.Aliases[name]
+		if alias.ProjectId == "" || alias.BuildTargetId == "" {
+			checks = append(checks, configCheck{"aliases." + name, checkFail, "needs both a projectId and a buildTargetId"})
+		} else {
+			checks = append(checks, passCheck("aliases."+name, alias.ProjectId+"/"+alias.BuildTargetId))
+		}
+	}
+
+	// the credentials as commands see them, with the keychain and profile fallbacks applied
+	profiles := append([]string{""}, data.ProfileNames()...)
+	for _, profile := range profiles {
+		prefix := "profiles." + profile + "."
+		if profile == "" {
+			prefix = ""
+		} else {
+			checkRegion(prefix+"region", data.Profiles[profile].Region)
+		}
+
+		apiKey, orgId, err := settings.GetCredentials(profile)
+		if err != nil {
+			checks = append(checks, failCheck(prefix+"apiKey", err))
+			continue
+		}
+
+		if apiKey == "" && orgId == "" && profile == "" && len(profiles) > 1 {
+			checks = append(checks, configCheck{"apiKey", checkSkip, "not set, profiles are used instead"})
+			continue
+		}
+
+		var keyErr error
+		if apiKey == "" {
+			keyErr = fmt.Errorf("not set, 'ucb config --setup' saves one")
+		} else {
+			keyErr = validators["apiKey"](apiKey)
+		}
+		checks = append(checks, checkResult(prefix+"apiKey", keyErr, maskKey(apiKey)))
+
+		var orgErr error
+		if orgId == "" {
+			orgErr = fmt.Errorf("not set")
+		}
+		checks = append(checks, checkResult(prefix+"orgId", orgErr, orgId))
+
+		if !live {
+			continue
+		}
+		if keyErr != nil || orgErr != nil {
+			checks = append(checks, configCheck{prefix + "whoami", checkSkip, "the api key or org id is invalid"})
+			continue
+		}
+		checks = append(checks, liveCheck(ctx, flags, prefix+"whoami", apiKey, orgId))
+	}
+
+	return checks, nil
+}
+
+// liveCheck confirms an api key works and can access its org
+func liveCheck(ctx context.Context, flags map[string]string, check, apiKey, orgId string) configCheck {
+	orgService := cloudbuild.NewOrgService(apiKey, orgId, serviceOptions(flags)...)
+
+	user, err := orgService.CurrentUserContext(ctx)
+	if err != nil {
+		return failCheck(check, err)
+	}
+
+	if _, err := orgService.NameContext(ctx); err != nil {
+		return configCheck{check, checkFail, fmt.Sprintf("%s can't access org %s: %v", user.Email, orgId, err)}
+	}
+	return passCheck(check, user.Email)
+}
+
+// maskKey hides all but the end of an api key so it can be recognised without being shown
+func maskKey(key string) string {
+	if len(key) <= 4 {
+		return strings.Repeat("*", len(key))
+	}
+	return strings.Repeat("*", len(key)-4) + key[len(key)-4:]
+}
+
+func failedChecks(checks []configCheck) int {
+	failed := 0
+	for _, check := range checks {
+		if check.Result == checkFail {
+			failed++
+		}
+	}
+	return failed
+}
@@ -0,0 +1,20 @@
+package cli
+
+import "gopkg.in/AlecAivazis/survey.v1"
+
+// confirm asks the user to confirm a destructive action, --yes skips the prompt
+func confirm(flags map[string]string, message string) (bool, error) {
+	if boolFlag(flags, "yes") {
+		return true, nil
+	}
+
+	if !isInteractive(flags) {
+		return false, validationErrorf("running non-interactively, pass --yes to confirm: %s", message)
+	}
+
+	ok := false
+	if err := survey.AskOne(&survey.Confirm{Message: message}, &ok, nil); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
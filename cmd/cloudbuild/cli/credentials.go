@@ -0,0 +1,325 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild/responses"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// isLabelConflict reports if an upload failed because a credential with the same label already exists
+func isLabelConflict(err error) bool {
+	uploadErr, ok := err.(*cloudbuild.UploadError)
+	if !ok {
+		return false
+	}
+
+	if uploadErr.StatusCode == http.StatusConflict {
+		return true
+	}
+
+	msg := strings.ToLower(uploadErr.Message + " " + uploadErr.Body)
+	return strings.Contains(msg, "already exists") || (uploadErr.Field == "label" && strings.Contains(msg, "exist"))
+}
+
+// uploadOrUpdateIOS uploads a credential, if the label is already taken the single credential with exactly that
+// label is updated instead
+func uploadOrUpdateIOS(ctx context.Context, flags map[string]string, credsService *cloudbuild.CredentialsService, label, certPath, profilePath, certPass string) (*responses.IOSCred, error) {
+	cred, uploadErr := credsService.UploadIOSContext(ctx, label, certPath, profilePath, certPass)
+	if !isLabelConflict(uploadErr) {
+		return cred, uploadErr
+	}
+
+	creds, err := credsService.GetAllIOSContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []responses.IOSCred
+	for _, existing := range creds {
+		if existing.Label == label {
+			matches = append(matches, existing)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, uploadErr
+	case 1:
+		fmt.Fprintf(progress(flags), "credential %q already exists, updating %s\n", label, matches[0].Id)
+		return credsService.UpdateIOSContext(ctx, matches[0].Id, label, certPath, profilePath, certPass)
+	default:
+		ids := make([]string, 0, len(matches))
+		for _, match := range matches {
+			ids = append(ids, match.Id)
+		}
+		return nil, fmt.Errorf("%d credentials are labelled %q, use updateCred with one of: %s", len(matches), label, strings.Join(ids, ", "))
+	}
+}
+
+// credDeletion is what deleteCred prints with --output, a failed delete is an error instead
+type credDeletion struct {
+	Id      string `json:"id"`
+	Deleted bool   `json:"deleted"`
+}
+
+type credRow struct {
+	Id         string    `json:"id"`
+	Label      string    `json:"label"`
+	Expiration time.Time `json:"expiration"`
+	Type       string    `json:"type"`
+}
+
+// iosCredRows summarises credentials for table and csv output, the expiration is the earlier of the certificate's
+// and the provisioning profile's and the type is the profile's
+func iosCredRows(creds []responses.IOSCred) []credRow {
+	rows := make([]credRow, 0, len(creds))
+	for _, cred := range creds {
+		rows = append(rows, credRow{cred.Id, cred.Label, cred.Expiration(), cred.ProvisioningProfile.Type})
+	}
+	return rows
+}
+
+// iosCredListing lists an org's credentials filtered and sorted by listCreds' flags, table and csv output get them
+// summarised by iosCredRows
+func iosCredListing(ctx context.Context, flags map[string]string, apiKey, orgId string) (interface{}, error) {
+	var window time.Duration
+	if val := flags["expiring-within"]; val != "" {
+		d, err := parseDuration(val)
+		if err != nil || d < 0 {
+			return nil, validationErrorf("--expiring-within: invalid duration %q", val)
+		}
+		window = d
+	}
+
+	credsService := cloudbuild.NewCredentialsService(apiKey, orgId, serviceOptions(flags)...)
+	creds, err := credsService.GetAllIOSContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if flags["expiring-within"] != "" {
+		creds = expiringCreds(creds, window, time.Now())
+	}
+
+	if err := sortCreds(creds, flags["sort"]); err != nil {
+		return nil, err
+	}
+
+	if format := flags["output"]; format == outputTable || format == outputCSV {
+		return iosCredRows(creds), nil
+	}
+	return creds, nil
+}
+
+func androidCredListing(ctx context.Context, flags map[string]string, apiKey, orgId string) (interface{}, error) {
+	credsService := cloudbuild.NewCredentialsService(apiKey, orgId, serviceOptions(flags)...)
+	return credsService.GetAllAndroidContext(ctx)
+}
+
+// sortCreds orders credentials by label or soonest expiration, an empty column keeps the api's order
+func sortCreds(creds []responses.IOSCred, by string) error {
+	switch by {
+	case "":
+	case "expiry":
+		// credentials without a known expiration go last
+		sort.SliceStable(creds, func(i, j int) bool {
+			a, b := creds[i].Expiration(), creds[j].Expiration()
+			if a.IsZero() || b.IsZero() {
+				return !a.IsZero() && b.IsZero()
+			}
+			return a.Before(b)
+		})
+	case "label":
+		sort.SliceStable(creds, func(i, j int) bool {
+			return strings.ToLower(creds[i].Label) < strings.ToLower(creds[j].Label)
+		})
+	default:
+		return validationErrorf("--sort: invalid column %q, must be expiry or label", by)
+	}
+	return nil
+}
+
+// expiringCreds keeps the credentials that have expired or expire within window of now
+func expiringCreds(creds []responses.IOSCred, window time.Duration, now time.Time) []responses.IOSCred {
+	deadline := now.Add(window)
+
+	expiring := make([]responses.IOSCred, 0, len(creds))
+	for _, cred := range creds {
+		if expiration := cred.Expiration(); !expiration.IsZero() && expiration.Before(deadline) {
+			expiring = append(expiring, cred)
+		}
+	}
+	return expiring
+}
+
+type targetAssignment struct {
+	BuildTargetId string `json:"buildtargetid"`
+	Error         string `json:"error,omitempty"`
+}
+
+type credAssignments struct {
+	Credential   *responses.IOSCred `json:"credential"`
+	BuildTargets []targetAssignment `json:"buildTargets"`
+}
+
+// assignToTargets sets credId as the signing credential of each build target, a failure is recorded against its
+// target without stopping the others
+func assignToTargets(ctx context.Context, targetsService *cloudbuild.BuildTargetsService, projectId, credId string, targetIds []string) ([]targetAssignment, error) {
+	assignments := make([]targetAssignment, 0, len(targetIds))
+	failed := 0
+
+	for _, targetId := range targetIds {
+		assignment := targetAssignment{BuildTargetId: targetId}
+		if _, err := targetsService.SetIOSCredentialContext(ctx, projectId, targetId, credId); err != nil {
+			if err == cloudbuild.ErrDryRun || ctx.Err() != nil {
+				return assignments, err
+			}
+			assignment.Error = err.Error()
+			failed++
+		}
+		assignments = append(assignments, assignment)
+	}
+
+	if failed > 0 {
+		return assignments, fmt.Errorf("%d of %d build targets failed", failed, len(targetIds))
+	}
+	return assignments, nil
+}
+
+// renewal is a certificate and provisioning profile pair found by renewProfiles, Skip says why it won't be uploaded
+type renewal struct {
+	Label       string `json:"label"`
+	CredId      string `json:"credentialid"`
+	CertPath    string `json:"certificate"`
+	ProfilePath string `json:"profile"`
+	Skip        string `json:"skip,omitempty"`
+}
+
+// renewalPlan pairs up the <label>.p12 and <label>.mobileprovision files in dir and matches each pair to the one
+// credential with that label
+func renewalPlan(dir string, creds []responses.IOSCred) ([]renewal, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := make(map[string]*renewal)
+	var labels []string
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		ext := filepath.Ext(file.Name())
+		label := strings.TrimSuffix(file.Name(), ext)
+
+		pair, ok := pairs[label]
+		if !ok {
+			pair = &renewal{Label: label}
+		}
+
+		switch strings.ToLower(ext) {
+		case ".p12":
+			pair.CertPath = filepath.Join(dir, file.Name())
+		case ".mobileprovision":
+			pair.ProfilePath = filepath.Join(dir, file.Name())
+		default:
+			continue
+		}
+
+		if !ok {
+			pairs[label] = pair
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+
+	plan := make([]renewal, 0, len(labels))
+	for _, label := range labels {
+		pair := pairs[label]
+
+		var matches []string
+		for _, cred := range creds {
+			if cred.Label == label {
+				matches = append(matches, cred.Id)
+			}
+		}
+
+		switch {
+		case pair.CertPath == "":
+			pair.Skip = "no " + label + ".p12"
+		case pair.ProfilePath == "":
+			pair.Skip = "no " + label + ".mobileprovision"
+		case len(matches) == 0:
+			pair.Skip = "no credential is labelled " + label
+		case len(matches) > 1:
+			pair.Skip = fmt.Sprintf("%d credentials are labelled %s", len(matches), label)
+		default:
+			pair.CredId = matches[0]
+		}
+
+		plan = append(plan, *pair)
+	}
+	return plan, nil
+}
+
+type renewalResult struct {
+	Label  string `json:"label"`
+	CredId string `json:"credentialid"`
+	Error  string `json:"error,omitempty"`
+}
+
+// renewCreds updates the credential of every pair in plan that isn't skipped, a failure is recorded against its
+// label without stopping the others
+func renewCreds(ctx context.Context, flags map[string]string, credsService *cloudbuild.CredentialsService, plan []renewal, certPass string) ([]renewalResult, error) {
+	var results []renewalResult
+	failed := 0
+
+	for _, pair := range plan {
+		if pair.Skip != "" {
+			continue
+		}
+
+		result := renewalResult{Label: pair.Label, CredId: pair.CredId}
+
+		err := checkSigningExpiry(flags, pair.CertPath, certPass, pair.ProfilePath)
+		if err == nil {
+			_, err = credsService.UpdateIOSContext(ctx, pair.CredId, pair.Label, pair.CertPath, pair.ProfilePath, certPass)
+		}
+		if err == cloudbuild.ErrDryRun || ctx.Err() != nil {
+			return results, err
+		} else if err != nil {
+			result.Error = err.Error()
+			failed++
+		}
+
+		results = append(results, result)
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d credentials failed", failed, len(results))
+	}
+	return results, nil
+}
+
+// checkNewLabel validates the label a credential is being renamed to, it has to be set and, ignoring case so the
+// two can be told apart, not be the label of any of the org's other credentials. labels maps credential ids to labels
+func checkNewLabel(credId, label string, labels map[string]string) error {
+	if strings.TrimSpace(label) == "" {
+		return validationErrorf("--label: the new label can't be empty")
+	}
+
+	for id, existing := range labels {
+		if id != credId && strings.EqualFold(existing, label) {
+			return validationErrorf("--label: %q is already the label of credential %s", existing, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// editorCommand builds the command used to edit path, preferring $VISUAL then $EDITOR
+// and falling back on vim, or notepad on windows
+func editorCommand(path string) (*exec.Cmd, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+
+	var args []string
+	if fields := strings.Fields(editor); len(fields) > 0 {
+		editor, args = fields[0], fields[1:]
+	} else if runtime.GOOS == "windows" {
+		editor = "notepad"
+	} else {
+		editor = "vim"
+	}
+
+	editorPath, err := exec.LookPath(editor)
+	if err != nil {
+		return nil, fmt.Errorf("could not find editor %q on PATH, set $EDITOR to the editor you want to use", editor)
+	}
+
+	return exec.Command(editorPath, append(args, path)...), nil
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ValidationError is returned when a command is given missing or invalid input
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// BuildFailedError is returned when a waited on build finishes without succeeding
+type BuildFailedError struct {
+	Number int
+	Status string
+}
+
+func (e *BuildFailedError) Error() string {
+	return fmt.Sprintf("build %d finished with status %s", e.Number, e.Status)
+}
+
+// BuildRunningError is returned when checking the status of a build that hasn't finished yet
+type BuildRunningError struct {
+	Number int
+	Status string
+}
+
+func (e *BuildRunningError) Error() string {
+	return fmt.Sprintf("build %d has not finished, its status is %s", e.Number, e.Status)
+}
+
+var (
+	errIsDir  = errors.New("is a directory, expected a file")
+	errNotDir = errors.New("not a directory")
+)
+
+// FileError is returned when a path given for a file or directory can't be used, Err is the error from checking it,
+// eg from os.Stat, or why the path is unsuitable
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	switch {
+	case os.IsNotExist(e.Err):
+		return fmt.Sprintf("%s: no such file or directory", e.Path)
+	case os.IsPermission(e.Err):
+		return fmt.Sprintf("%s: permission denied", e.Path)
+	}
+
+	if pathErr, ok := e.Err.(*os.PathError); ok {
+		return fmt.Sprintf("%s: %v", e.Path, pathErr.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+func validationErrorf(format string, a ...interface{}) error {
+	return &ValidationError{fmt.Errorf(format, a...)}
+}
@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild/responses"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+// credExport is the document exportCreds writes and importCreds reads, it only has what the api returns so no
+// private keys or passwords
+type credExport struct {
+	OrgId    string                  `json:"orgId"`
+	Exported time.Time               `json:"exported"`
+	IOS      []iosCredExport         `json:"ios"`
+	Android  []responses.AndroidCred `json:"android"`
+}
+
+// iosCredExport is an exported credential, certPath and profilePath can be added to point importCreds at the signing
+// files to upload, relative paths are from the export file's directory
+type iosCredExport struct {
+	responses.IOSCred
+	CertPath    string `json:"certPath,omitempty"`
+	ProfilePath string `json:"profilePath,omitempty"`
+}
+
+// exportCreds collects the metadata of every credential in the org
+func exportCreds(ctx context.Context, credsService *cloudbuild.CredentialsService, orgId string) (*credExport, error) {
+	iosCreds, err := credsService.GetAllIOSContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	androidCreds, err := credsService.GetAllAndroidContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &credExport{
+		OrgId:    orgId,
+		Exported: time.Now().UTC(),
+		IOS:      make([]iosCredExport, 0, len(iosCreds)),
+		Android:  androidCreds,
+	}
+	if export.Android == nil {
+		export.Android = []responses.AndroidCred{}
+	}
+	for _, cred := range iosCreds {
+		export.IOS = append(export.IOS, iosCredExport{IOSCred: cred})
+	}
+	return export, nil
+}
+
+// writeExport writes the export as indented json to path, - writes it to w
+func writeExport(w io.Writer, path string, export *credExport) error {
+	data, err := json.MarshalIndent(export, "", "    ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if path == "-" {
+		_, err := w.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+func readExport(path string) (*credExport, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, &FileError{path, err}
+	}
+
+	var export credExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, validationErrorf("--file: %s is not a credentials export: %v", path, err)
+	}
+	return &export, nil
+}
+
+// credImport is an exported ios credential and the signing files importCreds will upload for it, Skip says why it
+// won't be uploaded
+type credImport struct {
+	Label       string `json:"label"`
+	ExportedId  string `json:"exportedid"`
+	CertPath    string `json:"certificate"`
+	ProfilePath string `json:"profile"`
+	Skip        string `json:"skip,omitempty"`
+}
+
+// importPlan finds the signing files of each exported ios credential, the paths given in the export or else
+// <label>.p12 and <label>.mobileprovision in dir when it is set
+func importPlan(exportPath, dir string, export *credExport) []credImport {
+	base := filepath.Dir(exportPath)
+	resolve := func(path string) string {
+		if path = normalizePath(path); path != "" && !filepath.IsAbs(path) {
+			path = filepath.Join(base, path)
+		}
+		return path
+	}
+
+	plan := make([]credImport, 0, len(export.IOS))
+	for _, cred := range export.IOS {
+		item := credImport{Label: cred.Label, ExportedId: cred.Id, CertPath: resolve(cred.CertPath), ProfilePath: resolve(cred.ProfilePath)}
+
+		if dir != "" && item.CertPath == "" {
+			item.CertPath = filepath.Join(dir, cred.Label+".p12")
+		}
+		if dir != "" && item.ProfilePath == "" {
+			item.ProfilePath = filepath.Join(dir, cred.Label+".mobileprovision")
+		}
+
+		switch {
+		case item.Label == "":
+			item.Skip = "no label"
+		case item.CertPath == "":
+			item.Skip = "no certPath given and no --dir"
+		case item.ProfilePath == "":
+			item.Skip = "no profilePath given and no --dir"
+		default:
+			if err := fileExists(item.CertPath); err != nil {
+				item.Skip = err.Error()
+			} else if err := fileExists(item.ProfilePath); err != nil {
+				item.Skip = err.Error()
+			}
+		}
+
+		plan = append(plan, item)
+	}
+	return plan
+}
+
+type credImportResult struct {
+	Label      string `json:"label"`
+	ExportedId string `json:"exportedid"`
+	CredId     string `json:"credentialid,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// importCreds uploads the planned credentials, one already labelled the same way in the org is updated instead
+func importCreds(ctx context.Context, flags map[string]string, credsService *cloudbuild.CredentialsService, plan []credImport, certPass string) ([]credImportResult, error) {
+	var results []credImportResult
+	failed := 0
+
+	for _, item := range plan {
+		if item.Skip != "" {
+			continue
+		}
+
+		result := credImportResult{Label: item.Label, ExportedId: item.ExportedId}
+
+		err := checkSigningExpiry(flags, item.CertPath, certPass, item.ProfilePath)
+		if err == nil {
+			var cred *responses.IOSCred
+			if cred, err = uploadOrUpdateIOS(ctx, flags, credsService, item.Label, item.CertPath, item.ProfilePath, certPass); err == nil {
+				result.CredId = cred.Id
+			}
+		}
+		if err == cloudbuild.ErrDryRun || ctx.Err() != nil {
+			return results, err
+		} else if err != nil {
+			result.Error = err.Error()
+			failed++
+		}
+
+		results = append(results, result)
+	}
+
+	if failed > 0 {
+		return results, fmt.Errorf("%d of %d credentials failed", failed, len(results))
+	}
+	return results, nil
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+)
+
+// fieldName returns the name a struct field is printed under, its json name or the field name when it has none
+func fieldName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" {
+		return name
+	}
+	return field.Name
+}
+
+// selectFields returns data, a struct or a slice of them, with only the comma separated top level fields given,
+// fields are matched case insensitively by their json or field name and printed in the order given
+func selectFields(data interface{}, fields string) (interface{}, error) {
+	if fields == "" {
+		return data, nil
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(data))
+	if !v.IsValid() {
+		return data, nil
+	}
+
+	isList := v.Kind() == reflect.Slice || v.Kind() == reflect.Array
+
+	elemType := v.Type()
+	if isList {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	if elemType.Kind() != reflect.Struct {
+		return nil, validationErrorf("--fields: this command's output has no fields to select")
+	}
+
+	indexes, selected, err := lookupFields(elemType, fields)
+	if err != nil {
+		return nil, err
+	}
+	t := reflect.StructOf(selected)
+
+	pick := func(row reflect.Value) reflect.Value {
+		out := reflect.New(t).Elem()
+		if row = reflect.Indirect(row); row.IsValid() {
+			for i, index := range indexes {
+				out.Field(i).Set(row.Field(index))
+			}
+		}
+		return out
+	}
+
+	if !isList {
+		return pick(v).Interface(), nil
+	}
+
+	out := reflect.MakeSlice(reflect.SliceOf(t), v.Len(), v.Len())
+	for i := 0; i < v.Len(); i++ {
+		out.Index(i).Set(pick(v.Index(i)))
+	}
+	return out.Interface(), nil
+}
+
+// lookupFields finds the fields of t named in the comma separated list, returning their indexes in t and copies
+// of them to build a new struct from
+func lookupFields(t reflect.Type, fields string) ([]int, []reflect.StructField, error) {
+	var indexes []int
+	var selected []reflect.StructField
+	seen := make(map[int]bool)
+
+	for _, name := range strings.Split(fields, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		index := -1
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" || field.Anonymous || fieldName(field) == "-" {
+				continue
+			}
+			if strings.EqualFold(fieldName(field), name) || strings.EqualFold(field.Name, name) {
+				index = i
+				break
+			}
+		}
+
+		if index < 0 {
+			return nil, nil, validationErrorf("--fields: unknown field %q, must be one of: %s", name, strings.Join(fieldNames(t), ", "))
+		}
+		if seen[index] {
+			continue
+		}
+		seen[index] = true
+
+		field := t.Field(index)
+		indexes = append(indexes, index)
+		selected = append(selected, reflect.StructField{Name: field.Name, Type: field.Type, Tag: field.Tag})
+	}
+
+	if len(selected) == 0 {
+		return nil, nil, validationErrorf("--fields: no field names given")
+	}
+
+	return indexes, selected, nil
+}
+
+// fieldNames returns the names of the fields of t that can be selected
+func fieldNames(t reflect.Type) []string {
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Anonymous || fieldName(field) == "-" {
+			continue
+		}
+		names = append(names, fieldName(field))
+	}
+	return names
+}
@@ -3,23 +3,75 @@ package cli
 import (
 	"flag"
 	"github.com/cmcpasserby/ucb/cmd/cloudbuild/settings"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// envFlags maps global flags to the environment variables that can supply them
+var envFlags = map[string]string{
+	"apiKey":  "UCB_API_KEY",
+	"orgId":   "UCB_ORG_ID",
+	"api-url": "UCB_API_URL",
+	"region":  "UCB_REGION",
+}
+
+// configEnv overrides the config file path when --config is not given
+const configEnv = "UCB_CONFIG"
+
+// targetConfigCommands took their build target definition as --config before it became the global flag, for them
+// --config still names the definition and the config file comes from UCB_CONFIG
+var targetConfigCommands = map[string]bool{"createBuildTarget": true, "updateBuildTarget": true}
+
+const targetConfigUsage = "Path to a JSON build target definition, the same as --file, the config file comes from $UCB_CONFIG or ~/.cloudbuild"
+
+var globalFlags = []string{"apiKey", "orgId", "config", "profile", "target", "output", "fields", "raw", "no-interactive", "quiet", "verbose", "log-format", "request-timeout", "proxy", "api-url", "region", "dry-run", "force", "input-file", "no-color", "offline-queue"}
+
+// IsGlobalFlag reports if name is a flag shared by every command
+func IsGlobalFlag(name string) bool {
+	for _, f := range globalFlags {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
 func CreateFlagSet(name string) *flag.FlagSet {
-	fs := flag.NewFlagSet(name, flag.ExitOnError)
-	fs.String("apiKey", "", "Api Key")
-	fs.String("orgId", "", "Organization Id")
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.String("apiKey", "", "Api Key, defaults to $UCB_API_KEY or the config file where 'ucb config --setup' saves it")
+	fs.Var(new(orgIdsValue), "orgId", "Organization Id, defaults to $UCB_ORG_ID or the config file, listProjects, listCreds and listAndroidCreds take several separated by commas or by repeating the flag")
+	fs.String("config", "", "Path of the config file, defaults to $UCB_CONFIG or ~/.cloudbuild")
+	fs.String("profile", "", "Config file profile to read the api key and org id from")
+	fs.String("target", "", "Favourite build target to use the project and build target ids of, eg @ios-prod")
+	fs.String("output", "", "Output format (json, yaml, table or csv)")
+	fs.String("fields", "", "Comma separated fields to print, eg buildtargetid,buildStatus")
+	fs.Bool("raw", false, "Print the api's json responses as they were returned instead of the usual output")
+	fs.Bool("no-interactive", false, "Fail instead of prompting for missing values, implied by CI=true")
+	fs.Bool("quiet", false, "Print nothing but errors, the exit code still reports failures")
+	fs.Bool("verbose", false, "Log http requests and responses to stderr")
+	fs.String("log-format", logFormatText, "Request log format (text or json), json implies --verbose")
+	fs.String("request-timeout", cloudbuild.DefaultTimeout.String(), "How long each api request may wait for a response, 0 waits forever")
+	fs.String("proxy", "", "Proxy url for api requests, overrides HTTPS_PROXY and NO_PROXY")
+	fs.String("api-url", "", "Base url of the api, eg a mock server, defaults to $UCB_API_URL or the Unity Cloud Build api")
+	fs.String("region", "", "Region the org's data is kept in, selects the api url, defaults to $UCB_REGION or the config file")
+	fs.Bool("dry-run", false, "Print requests that would change data instead of sending them")
+	fs.Bool("force", false, "Change or delete things in a protected org without typing its name")
+	fs.Bool("offline-queue", false, "Queue changes that can't reach the api instead of failing, 'ucb flushQueue' sends them later")
+	fs.Bool("no-color", false, "Don't color statuses and errors, also set by the NO_COLOR environment variable, colors are only used on a terminal")
+	fs.String("input-file", "", "Json file of answers keyed by flag name, used for flags that aren't given before prompting")
 	return fs
 }
 
 func ParseFlags(set *flag.FlagSet, args []string) (map[string]string, error) {
-	data, err := settings.ParseDotFile()
-	if err != nil {
-		return nil, err
-	}
-
 	if err := set.Parse(args); err != nil {
-		return nil, err
+		if err == flag.ErrHelp {
+			return nil, err
+		}
+		return nil, &ValidationError{err}
 	}
 
 	flagMap := make(map[string]string)
@@ -28,14 +80,175 @@ func ParseFlags(set *flag.FlagSet, args []string) (map[string]string, error) {
 		flagMap[flag.Name] = flag.Value.String()
 	})
 
-	// apply from dot settings if not defined as flags
-	if _, ok := flagMap["apiKey"]; !ok {
-		flagMap["apiKey"] = data.ApiKey
+	// answers from an input file fill in this command's flags that weren't given, ahead of env vars and the config file
+	if inputPath := flagMap["input-file"]; inputPath != "" {
+		answers, err := readInputFile(inputPath)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range answers {
+			if _, given := flagMap[name]; given || name == "input-file" || set.Lookup(name) == nil {
+				continue
+			}
+			if err := set.Set(name, value); err != nil {
+				return nil, validationErrorf("--input-file: %s: %v", name, err)
+			}
+			flagMap[name] = set.Lookup(name).Value.String()
+		}
+	}
+
+	// existing createBuildTarget --config target.json invocations keep working
+	if configPath, ok := flagMap["config"]; ok && targetConfigCommands[set.Name()] {
+		if _, given := flagMap["file"]; !given {
+			flagMap["file"] = configPath
+		}
+		delete(flagMap, "config")
+	}
+
+	noColor = boolFlag(flagMap, "no-color")
+
+	if configPath, ok := flagMap["config"]; ok && configPath != "" {
+		settings.SetFilePath(configPath)
+	} else if configPath := os.Getenv(configEnv); configPath != "" {
+		settings.SetFilePath(configPath)
+	}
+
+	apiKey, orgId, err := settings.GetCredentials(flagMap["profile"])
+	if _, notFound := err.(*settings.ProfileNotFoundError); notFound && set.Name() == "config" {
+		// config --setup is how new profiles get created
+		apiKey, orgId, err = settings.GetCredentials("")
+	}
+	if _, invalid := err.(*settings.ConfigError); invalid && set.Name() == "validateConfig" {
+		// validateConfig reports what is wrong with the file itself
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// an alias fills in the project and build target ids unless they were given
+	if target := flagMap["target"]; target != "" {
+		alias, err := settings.GetAlias(target)
+		if err != nil {
+			return nil, err
+		}
+
+		ids := map[string]string{
+			"projectId":     alias.ProjectId,
+			"buildTargetId": alias.BuildTargetId,
+		}
+		for name, value := range ids {
+			if _, given := flagMap[name]; !given && value != "" && set.Lookup(name) != nil {
+				flagMap[name] = value
+			}
+		}
+	}
+
+	// fill in config file defaults for flags this command has but that weren't given
+	if data, err := settings.ParseDotFile(); err == nil {
+		for name, value := range data.Defaults.Flags() {
+			if _, given := flagMap[name]; !given && set.Lookup(name) != nil {
+				flagMap[name] = value
+			}
+		}
+	}
+
+	if err := validateOutputFormat(flagMap["output"]); err != nil {
+		return nil, err
+	}
+
+	if err := validateLogFormat(flagMap["log-format"]); err != nil {
+		return nil, err
+	}
+
+	if val := flagMap["proxy"]; val != "" {
+		if u, err := url.Parse(val); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") {
+			return nil, validationErrorf("--proxy: invalid proxy url %q", val)
+		}
+	}
+
+	if val, ok := flagMap["request-timeout"]; ok {
+		if timeout, err := parseDuration(val); err != nil || timeout < 0 {
+			return nil, validationErrorf("--request-timeout: invalid duration %q", val)
+		}
+	}
+
+	if val, ok := flagMap["page-size"]; ok {
+		if n, err := strconv.Atoi(val); err != nil || n < 1 {
+			return nil, validationErrorf("--page-size: invalid page size %q", val)
+		}
+	}
+
+	if val, ok := flagMap["api-url"]; ok {
+		if err := validateAPIURL(val); err != nil {
+			return nil, validationErrorf("--api-url: %v", err)
+		}
+	}
+
+	// apply from env vars then dot settings if not defined as flags
+	dotValues := map[string]string{
+		"apiKey": apiKey,
+		"orgId":  orgId,
 	}
 
-	if _, ok := flagMap["orgId"]; !ok {
-		flagMap["orgId"] = data.OrgId
+	for name, envName := range envFlags {
+		if _, ok := flagMap[name]; ok {
+			continue
+		}
+
+		if val := os.Getenv(envName); val != "" {
+			if validator, ok := validators[name]; ok {
+				if err := validator(val); err != nil {
+					return nil, validationErrorf("%s: %v", envName, err)
+				}
+			}
+			flagMap[name] = val
+		} else if dotVal, ok := dotValues[name]; ok {
+			flagMap[name] = dotVal
+		}
+	}
+
+	if err := applyRegion(flagMap); err != nil && set.Name() != "validateConfig" {
+		return nil, err
 	}
 
 	return flagMap, nil
 }
+
+// splitList splits a comma separated flag value, dropping empty entries
+func splitList(val string) []string {
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func boolFlag(flags map[string]string, name string) bool {
+	val, err := strconv.ParseBool(flags[name])
+	return err == nil && val
+}
+
+// isInteractive reports if missing values may be prompted for, this is disabled by --no-interactive or CI=true
+func isInteractive(flags map[string]string) bool {
+	if boolFlag(flags, "no-interactive") {
+		return false
+	}
+
+	ci, err := strconv.ParseBool(os.Getenv("CI"))
+	return err != nil || !ci
+}
+
+// parseDuration extends time.ParseDuration with a d suffix for days, eg 30d
+func parseDuration(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(s, "d"), 64)
+		if err != nil {
+			return 0, validationErrorf("invalid duration %q", s)
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+	return time.ParseDuration(s)
+}
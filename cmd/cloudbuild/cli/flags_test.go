@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"github.com/cmcpasserby/ucb/cmd/cloudbuild/settings"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildTargetConfigFlag(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "settings.toml")
+	targetPath := filepath.Join(dir, "target.json")
+	ioutil.WriteFile(configPath, []byte(""), 0600)
+	ioutil.WriteFile(targetPath, []byte(`{"name":"ios-release","platform":"ios"}`), 0600)
+
+	setenv(t, configEnv, configPath)
+	t.Cleanup(func() { settings.SetFilePath("") })
+
+	flags, err := ParseFlags(Commands["createBuildTarget"].Flags, []string{
+		"--apiKey", testApiKey, "--orgId", testOrgId, "--projectId", "my-game", "--config", targetPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if flags["file"] != targetPath {
+		t.Errorf("--file = %q, want the --config path %s", flags["file"], targetPath)
+	}
+	if path, _ := settings.GetFilePath(); path != configPath {
+		t.Errorf("the settings were read from %s, want %s", path, configPath)
+	}
+}
+
+func TestWaitBuildTimeoutFlag(t *testing.T) {
+	useConfig(t, "")
+
+	flags, err := ParseFlags(Commands["waitBuild"].Flags, []string{
+		"--apiKey", testApiKey, "--orgId", testOrgId, "--timeout", "2h", "--request-timeout", "10s",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// --timeout is the overall wait deadline, the per request timeout has its own flag
+	if flags["timeout"] != "2h" || flags["request-timeout"] != "10s" {
+		t.Errorf("got --timeout %q --request-timeout %q, want 2h and 10s", flags["timeout"], flags["request-timeout"])
+	}
+}
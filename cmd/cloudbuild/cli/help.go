@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// PrintCommandHelp writes a command's description, flags with their defaults and examples to w
+func PrintCommandHelp(w io.Writer, cmd Command) {
+	meta := cmd.Meta
+
+	fmt.Fprintln(w, meta.Summary)
+	if meta.Description != "" {
+		fmt.Fprintf(w, "\n%s\n", meta.Description)
+	}
+	fmt.Fprintf(w, "\nusage:\n  ucb %s [flags]\n", meta.Name)
+
+	WriteFlags(w, "flags", meta.CommandFlags())
+	WriteFlags(w, "global flags", meta.GlobalFlags())
+
+	if len(meta.Examples) > 0 {
+		fmt.Fprintln(w, "\nexamples:")
+		for _, example := range meta.Examples {
+			fmt.Fprintf(w, "  %s\n", example)
+		}
+	}
+}
+
+// WriteFlags writes a titled list of flags with their descriptions and defaults to w, nothing is written for no flags
+func WriteFlags(w io.Writer, title string, flags []FlagMeta) {
+	if len(flags) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n%s:\n", title)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, f := range flags {
+		arg := "--" + f.Name
+		if f.Arg != "" {
+			arg += " <" + f.Arg + ">"
+		}
+
+		usage := f.Usage
+		if f.Default != "" {
+			usage += fmt.Sprintf(" (default %s)", f.Default)
+		}
+
+		fmt.Fprintf(tw, "  %s\t%s\n", arg, usage)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// readInputFile reads a json object of answers keyed by flag or survey field name, eg {"apiKey": "...", "certId": "..."},
+// values may be strings, numbers, bools or lists of them which are joined with commas
+func readInputFile(path string) (map[string]string, error) {
+	path = normalizePath(path)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, validationErrorf("--input-file: %w", &FileError{path, err})
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var raw map[string]interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, validationErrorf("--input-file: %s: expected a json object of answers: %v", path, err)
+	}
+
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	answers := make(map[string]string, len(raw))
+	for _, name := range names {
+		if raw[name] == nil {
+			continue
+		}
+
+		val, err := inputValue(raw[name])
+		if err != nil {
+			return nil, validationErrorf("--input-file: %s: %s: %v", path, name, err)
+		}
+		answers[name] = val
+	}
+
+	return answers, nil
+}
+
+func inputValue(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case json.Number:
+		return val.String(), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case []interface{}:
+		items := make([]string, 0, len(val))
+		for _, item := range val {
+			if _, isList := item.([]interface{}); isList {
+				return "", fmt.Errorf("lists can't be nested")
+			}
+			str, err := inputValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, str)
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", fmt.Errorf("expected a string, number, bool or list of them")
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"flag"
+	"strings"
+)
+
+// CommandMeta describes a command for help and shell completion, both render from it so they can't drift apart
+type CommandMeta struct {
+	Name        string
+	Summary     string
+	Description string // longer explanation printed by 'ucb help <command>', empty when the summary says it all
+	Examples    []string
+	Flags       []FlagMeta // filled in from the command's flag set when it is registered
+}
+
+// FlagMeta describes one of a command's flags
+type FlagMeta struct {
+	Name    string
+	Arg     string // placeholder for the flag's value, eg value or int, empty for bool flags
+	Usage   string
+	Default string // empty when the default is the zero value
+	Bool    bool
+	Global  bool
+}
+
+// flag descriptions are declared with each flag, they're copied into the meta of the commands in Commands once here
+func init() {
+	for key, cmd := range Commands {
+		cmd.Meta.Flags = flagMetas(cmd.Flags)
+		Commands[key] = cmd
+	}
+}
+
+// registerCommand adds a command that can't be part of the Commands literal, filling in its flag descriptions
+func registerCommand(cmd Command) {
+	cmd.Meta.Flags = flagMetas(cmd.Flags)
+	Commands[cmd.Meta.Name] = cmd
+}
+
+// GlobalFlags describes the flags every command shares
+func GlobalFlags() []FlagMeta {
+	return flagMetas(CreateFlagSet(""))
+}
+
+func flagMetas(set *flag.FlagSet) []FlagMeta {
+	var flags []FlagMeta
+	set.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, flagMeta(f))
+	})
+	return flags
+}
+
+// CommandFlags returns the flags of the command that aren't shared by every command
+func (meta CommandMeta) CommandFlags() []FlagMeta {
+	return filterFlags(meta.Flags, false)
+}
+
+// GlobalFlags returns the flags of the command that every command shares
+func (meta CommandMeta) GlobalFlags() []FlagMeta {
+	return filterFlags(meta.Flags, true)
+}
+
+func filterFlags(flags []FlagMeta, global bool) []FlagMeta {
+	var matched []FlagMeta
+	for _, f := range flags {
+		if f.Global == global {
+			matched = append(matched, f)
+		}
+	}
+	return matched
+}
+
+func flagMeta(f *flag.Flag) FlagMeta {
+	arg, usage := flag.UnquoteUsage(f)
+	if arg == "string" {
+		arg = "value"
+	}
+
+	meta := FlagMeta{
+		Name:   f.Name,
+		Arg:    arg,
+		Usage:  strings.Replace(usage, "\n", " ", -1),
+		Bool:   isBoolFlag(f),
+		Global: IsGlobalFlag(f.Name),
+	}
+
+	if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+		meta.Default = f.DefValue
+	}
+	return meta
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
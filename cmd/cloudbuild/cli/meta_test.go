@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestCommandMetaFlags(t *testing.T) {
+	for key, cmd := range Commands {
+		if cmd.Meta.Name != key {
+			t.Errorf("%s is registered with the meta of %s", key, cmd.Meta.Name)
+		}
+
+		declared := 0
+		cmd.Flags.VisitAll(func(f *flag.Flag) { declared++ })
+		if len(cmd.Meta.Flags) != declared {
+			t.Errorf("%s meta has %d flags, its flag set %d", key, len(cmd.Meta.Flags), declared)
+		}
+	}
+}
+
+func TestPrintCommandHelp(t *testing.T) {
+	var b bytes.Buffer
+	PrintCommandHelp(&b, Commands["updateCred"])
+
+	help := b.String()
+	meta := Commands["updateCred"].Meta
+	for _, want := range []string{meta.Summary, meta.Description, meta.Examples[0], "--update-unchanged", "--apiKey <value>"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help is missing %q:\n%s", want, help)
+		}
+	}
+}
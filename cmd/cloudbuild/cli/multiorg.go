@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// orgIdsValue is the --orgId flag, given more than once its values are joined with commas the same as a comma
+// separated list. Only listProjects, listCreds and listAndroidCreds accept more than one org
+type orgIdsValue []string
+
+func (v *orgIdsValue) String() string {
+	return strings.Join(*v, ",")
+}
+
+func (v *orgIdsValue) Set(s string) error {
+	*v = append(*v, s)
+	return nil
+}
+
+// orgIds returns the org ids given with --orgId, UCB_ORG_ID or the config file
+func orgIds(flags map[string]string) []string {
+	return splitList(flags["orgId"])
+}
+
+// orgListing fetches one org's results ready to print, a slice of structs
+type orgListing func(ctx context.Context, flags map[string]string, apiKey, orgId string) (interface{}, error)
+
+// listOrgs prints the results of list for every org with each row tagged by the org it came from. An org that fails
+// has its error printed and the others are still listed
+func listOrgs(ctx context.Context, flags map[string]string, format string, orgs []string, list orgListing) error {
+	results := struct {
+		ApiKey string `survey:"apiKey" global:"true"`
+	}{}
+
+	if err := populateGlobalArgs(flags, &results); err != nil {
+		return err
+	}
+
+	for _, orgId := range orgs {
+		if err := validators["orgId"](orgId); err != nil {
+			return validationErrorf("orgId: %s: %w", orgId, err)
+		}
+	}
+
+	var rows reflect.Value
+	failed := 0
+
+	for _, orgId := range orgs {
+		data, err := list(ctx, flags, results.ApiKey, orgId)
+		if err == nil {
+			rows, err = appendOrgRows(rows, orgId, data)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		} else if _, invalid := err.(*ValidationError); invalid {
+			// bad flags fail every org the same way
+			return err
+		} else if err != nil {
+			fmt.Fprintf(progress(flags), "org %s: %v\n", orgId, err)
+			failed++
+		}
+	}
+
+	if rows.IsValid() {
+		if err := prettyPrint(stdout(flags), format, flags["fields"], rows.Interface()); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d orgs failed", failed, len(orgs))
+	}
+	return nil
+}
+
+// appendOrgRows appends data, a slice of structs, to rows as structs with an orgId field ahead of their own. rows is
+// created on the first call
+func appendOrgRows(rows reflect.Value, orgId string, data interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() != reflect.Struct {
+		return rows, fmt.Errorf("can't tag %T results with their org", data)
+	}
+	elemType := v.Type().Elem()
+
+	if !rows.IsValid() {
+		fields := []reflect.StructField{{Name: "OrgId", Type: reflect.TypeOf(""), Tag: `json:"orgId"`}}
+		for i := 0; i < elemType.NumField(); i++ {
+			if field := elemType.Field(i); field.PkgPath == "" && !field.Anonymous && field.Name != "OrgId" {
+				fields = append(fields, reflect.StructField{Name: field.Name, Type: field.Type, Tag: field.Tag})
+			}
+		}
+		rows = reflect.MakeSlice(reflect.SliceOf(reflect.StructOf(fields)), 0, v.Len())
+	}
+
+	rowType := rows.Type().Elem()
+	for i := 0; i < v.Len(); i++ {
+		row := reflect.New(rowType).Elem()
+		row.Field(0).SetString(orgId)
+		for j := 1; j < rowType.NumField(); j++ {
+			row.Field(j).Set(v.Index(i).FieldByName(rowType.Field(j).Name))
+		}
+		rows = reflect.Append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/cmcpasserby/ucb/cmd/cloudbuild/settings"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
+	"gopkg.in/AlecAivazis/survey.v1"
+	"strings"
+	"time"
+)
+
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+// parseTime accepts an RFC 3339 timestamp or a plain date, values without a zone are in local time
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, validationErrorf("invalid time %q, expected a date like 2019-01-31 or 2019-01-31T15:04:05Z", s)
+}
+
+// parseSince accepts a duration before now, eg 7d or 12h, or a time parseTime accepts
+func parseSince(s string) (time.Time, error) {
+	if d, err := parseDuration(s); err == nil && d >= 0 {
+		return time.Now().Add(-d), nil
+	}
+
+	t, err := parseTime(s)
+	if err != nil {
+		return time.Time{}, validationErrorf("invalid time %q, expected a duration like 7d or a date like 2019-01-31 or 2019-01-31T15:04:05Z", s)
+	}
+	return t, nil
+}
+
+func auditLogOptions(flags map[string]string) (cloudbuild.AuditLogOptions, error) {
+	opts := cloudbuild.AuditLogOptions{Actor: flags["actor"]}
+
+	if val := flags["since"]; val != "" {
+		t, err := parseTime(val)
+		if err != nil {
+			return opts, validationErrorf("--since: %v", err)
+		}
+		opts.Since = t
+	}
+
+	if val := flags["until"]; val != "" {
+		t, err := parseTime(val)
+		if err != nil {
+			return opts, validationErrorf("--until: %v", err)
+		}
+		opts.Until = t
+	}
+
+	return opts, nil
+}
+
+// guardProtectedOrg makes the user type the name of an org listed in the config file's protectedOrgs before
+// anything in it is changed or deleted, --force skips the check and so does --dry-run as nothing is changed
+func guardProtectedOrg(ctx context.Context, flags map[string]string, apiKey, orgId string) error {
+	if boolFlag(flags, "force") || boolFlag(flags, "dry-run") {
+		return nil
+	}
+
+	data, err := settings.ParseDotFile()
+	if err != nil {
+		return err
+	}
+	if !data.IsProtectedOrg(orgId) {
+		return nil
+	}
+
+	name := orgId
+	orgService := cloudbuild.NewOrgService(apiKey, orgId, serviceOptions(flags)...)
+	if orgName, err := orgService.NameContext(ctx); err == nil && orgName != "" {
+		name = orgName
+	}
+
+	if !isInteractive(flags) {
+		return validationErrorf("%s is a protected org, pass --force to change it non-interactively", name)
+	}
+
+	typed := ""
+	prompt := &survey.Input{Message: fmt.Sprintf("%s is a protected org, type its name to continue:", name)}
+	if err := survey.AskOne(prompt, &typed, nil); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(typed) != name {
+		return validationErrorf("%q doesn't match the org name %s, nothing was changed", typed, name)
+	}
+	return nil
+}
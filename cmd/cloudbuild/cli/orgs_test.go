@@ -0,0 +1,21 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCreateHookProtectedOrg(t *testing.T) {
+	api := newFakeApi(t)
+
+	flags := commandFlags(t, api, map[string]string{"projectId": "my-game", "url": "https://hooks.example.com/build"})
+	useConfig(t, `protectedOrgs = ["example"]`)
+
+	err := runCommand("createHook", flags)
+	if err == nil || !strings.Contains(err.Error(), "protected org") {
+		t.Fatalf("got %v, want the protected org to be refused", err)
+	}
+	if api.received("POST /api/v1/orgs/example/projects/my-game/hooks") {
+		t.Error("the hook was created in a protected org")
+	}
+}
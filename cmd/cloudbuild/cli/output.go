@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputTable = "table"
+	outputCSV   = "csv"
+)
+
+var outputFormats = []string{outputJSON, outputYAML, outputTable, outputCSV}
+
+func validateOutputFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+
+	for _, f := range outputFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return validationErrorf("invalid output format %q, must be one of: %s", format, strings.Join(outputFormats, ", "))
+}
+
+// stdout is where a command writes its results, they are discarded with --quiet and with --raw, which prints the
+// api's responses instead
+func stdout(flags map[string]string) io.Writer {
+	if boolFlag(flags, "quiet") || boolFlag(flags, "raw") {
+		return ioutil.Discard
+	}
+	return os.Stdout
+}
+
+// progress is where a command writes status messages and warnings, they are discarded with --quiet while errors
+// are still returned
+func progress(flags map[string]string) io.Writer {
+	if boolFlag(flags, "quiet") {
+		return ioutil.Discard
+	}
+	return os.Stderr
+}
+
+// prettyPrint writes data to w in the given format, fields holding secrets are masked. When fields is given only
+// those comma separated top level fields are printed
+func prettyPrint(w io.Writer, format, fields string, data interface{}) error {
+	data, err := selectFields(data, fields)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case outputYAML:
+		return printYAML(w, redactData(data))
+	case outputTable:
+		return printTable(w, data)
+	case outputCSV:
+		return printCSV(w, data)
+	case "", outputJSON:
+		data = redactData(data)
+		if s, err := json.MarshalIndent(data, "", "    "); err == nil {
+			_, err := fmt.Fprintln(w, string(s))
+			return err
+		}
+
+		_, err := fmt.Fprintf(w, "%+v\n", data)
+		return err
+	default:
+		return validateOutputFormat(format)
+	}
+}
+
+// printYAML round trips data through json so the yaml keys match the api's field names
+func printYAML(w io.Writer, data interface{}) error {
+	s, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var generic interface{}
+	if err := yaml.Unmarshal(s, &generic); err != nil {
+		return err
+	}
+
+	out, err := yaml.Marshal(generic)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(w, string(out))
+	return err
+}
+
+type tableColumn struct {
+	name   string
+	index  int
+	secret bool
+}
+
+func printTable(out io.Writer, data interface{}) error {
+	header, rows := tabulate(data)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	if header != nil {
+		for i := range header {
+			header[i] = strings.ToUpper(header[i])
+		}
+		if colorEnabled(out) {
+			colorStatusColumns(header, rows)
+		}
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+	}
+
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	return w.Flush()
+}
+
+// printCSV writes the same columns as printTable with a header row of the json field names, values holding
+// commas, quotes or newlines are quoted
+func printCSV(out io.Writer, data interface{}) error {
+	header, rows := tabulate(data)
+
+	w := csv.NewWriter(out)
+
+	if header != nil {
+		if err := w.Write(header); err != nil {
+			return err
+		}
+	}
+
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// tabulate formats data, a struct or a slice of them, as rows of cells with the json field names as the header,
+// other values become a single column without a header
+func tabulate(data interface{}) (header []string, cells [][]string) {
+	v := reflect.Indirect(reflect.ValueOf(data))
+
+	var rows []reflect.Value
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		for i := 0; i < v.Len(); i++ {
+			rows = append(rows, reflect.Indirect(v.Index(i)))
+		}
+	} else {
+		rows = append(rows, v)
+	}
+
+	elemType := v.Type()
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	if elemType.Kind() != reflect.Struct {
+		for _, row := range rows {
+			cells = append(cells, []string{formatCell(row)})
+		}
+		return nil, cells
+	}
+
+	columns := tableColumns(elemType)
+
+	header = make([]string, 0, len(columns))
+	for _, col := range columns {
+		header = append(header, col.name)
+	}
+
+	for _, row := range rows {
+		rowCells := make([]string, 0, len(columns))
+		for _, col := range columns {
+			if col.secret {
+				rowCells = append(rowCells, cloudbuild.Redacted)
+			} else {
+				rowCells = append(rowCells, formatCell(row.Field(col.index)))
+			}
+		}
+		cells = append(cells, rowCells)
+	}
+
+	return header, cells
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// tableColumns returns the fields of t that can be shown in a single cell, nested values are skipped
+func tableColumns(t reflect.Type) []tableColumn {
+	columns := make([]tableColumn, 0, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := fieldName(field)
+		if name == "-" {
+			continue
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			if field.Type != timeType {
+				continue
+			}
+		case reflect.Map, reflect.Slice, reflect.Array, reflect.Ptr, reflect.Interface, reflect.Func, reflect.Chan:
+			continue
+		}
+
+		columns = append(columns, tableColumn{name, i, isSecretField(field)})
+	}
+
+	return columns
+}
+
+func formatCell(v reflect.Value) string {
+	if t, ok := v.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v.Interface())
+}
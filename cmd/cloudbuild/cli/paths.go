@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// shellUnescaper undoes the backslash escaping terminals apply to dragged and dropped paths, eg My\ Project.p12
+var shellUnescaper = strings.NewReplacer(
+	`\ `, " ",
+	`\(`, "(",
+	`\)`, ")",
+	`\'`, "'",
+	`\"`, `"`,
+	`\&`, "&",
+	`\\`, `\`,
+)
+
+// normalizePath cleans up a path pasted or dragged into a terminal, surrounding quotes are stripped and, other than
+// on windows where backslash is the path separator, escaped spaces and shell characters are unescaped. A leading ~
+// is expanded to the home directory
+func normalizePath(s string) string {
+	s = strings.TrimSpace(s)
+
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		s = s[1 : len(s)-1]
+	} else if runtime.GOOS != "windows" {
+		s = shellUnescaper.Replace(s)
+	}
+
+	return expandHome(s)
+}
+
+// expandHome replaces a leading ~ with the current user's home directory, ~user forms are left alone
+func expandHome(s string) string {
+	if s != "~" && !strings.HasPrefix(s, "~/") && !strings.HasPrefix(s, "~"+string(filepath.Separator)) {
+		return s
+	}
+
+	usr, err := user.Current()
+	if err != nil {
+		return s
+	}
+	return filepath.Join(usr.HomeDir, s[1:])
+}
+
+// normalizePaths applies normalizePath to every file path field of a populated args struct
+func normalizePaths(data interface{}) {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	tt := v.Type()
+
+	for i := 0; i < v.NumField(); i++ {
+		if tt.Field(i).Tag.Get("type") == "filePath" {
+			v.Field(i).SetString(normalizePath(v.Field(i).String()))
+		}
+	}
+}
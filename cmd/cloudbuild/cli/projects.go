@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/cmcpasserby/ucb/cmd/cloudbuild/settings"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild/responses"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultConcurrency = 4
+	defaultCacheTTL    = "5m"
+)
+
+func concurrencyFlag(flags map[string]string) (int, error) {
+	val, ok := flags["concurrency"]
+	if !ok {
+		return defaultConcurrency, nil
+	}
+
+	n, err := strconv.Atoi(val)
+	if err != nil || n < 1 {
+		return 0, validationErrorf("--concurrency: invalid worker count %q", val)
+	}
+	return n, nil
+}
+
+// projectIds returns the ids from --projectIds, or every project in the org when it is not set
+func projectIds(ctx context.Context, flags map[string]string, apiKey, orgId string) ([]string, error) {
+	if val := flags["projectIds"]; val != "" {
+		return splitList(val), nil
+	}
+
+	projectService := cloudbuild.NewProjectsService(apiKey, orgId, serviceOptions(flags)...)
+	projects, err := projectService.ListAllContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(projects))
+	for _, proj := range projects {
+		ids = append(ids, proj.Id)
+	}
+	return ids, nil
+}
+
+type projectBuildTargets struct {
+	ProjectId    string                  `json:"projectId"`
+	BuildTargets []responses.BuildTarget `json:"buildTargets,omitempty"`
+	Error        string                  `json:"error,omitempty"`
+}
+
+// projectBatchError reports how many projects of a batch failed, the failures themselves are part of the output
+func projectBatchError(results []cloudbuild.ProjectResult) error {
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d projects failed", failed, len(results))
+}
+
+// cachedProjects lists the org's projects, going through the on disk cache when it is enabled by --cache or the
+// config file and not disabled with --no-cache, --refresh skips reading the cache but still updates it
+func cachedProjects(ctx context.Context, flags map[string]string, apiKey, orgId string) ([]responses.Project, error) {
+	useCache := boolFlag(flags, "cache")
+	if !useCache {
+		if data, err := settings.ParseDotFile(); err == nil {
+			useCache = data.CacheProjects
+		}
+	}
+	if boolFlag(flags, "no-cache") {
+		useCache = false
+	}
+
+	ttlVal := defaultCacheTTL
+	if val, ok := flags["cache-ttl"]; ok {
+		ttlVal = val
+	}
+	ttl, err := parseDuration(ttlVal)
+	if err != nil {
+		return nil, validationErrorf("--cache-ttl: invalid duration %q", ttlVal)
+	}
+
+	key := "projects/" + orgId
+
+	var projects []responses.Project
+	if useCache && !boolFlag(flags, "refresh") {
+		if ok, err := settings.ReadCache(key, ttl, &projects); err == nil && ok {
+			return projects, nil
+		}
+	}
+
+	projectService := cloudbuild.NewProjectsService(apiKey, orgId, serviceOptions(flags)...)
+	projects, err = projectService.ListAllContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if useCache {
+		if err := settings.WriteCache(key, projects); err != nil {
+			fmt.Fprintf(progress(flags), "could not update the projects cache: %v\n", err)
+		}
+	}
+
+	return projects, nil
+}
+
+// projectListing lists an org's projects filtered and sorted by listProjects' flags, without --output they are
+// summarised by projectRows
+func projectListing(ctx context.Context, flags map[string]string, apiKey, orgId string) (interface{}, error) {
+	projects, err := cachedProjects(ctx, flags, apiKey, orgId)
+	if err != nil {
+		return nil, err
+	}
+
+	projects = filterProjects(projects, flags["filter"])
+	if err := sortProjects(projects, flags["sort"]); err != nil {
+		return nil, err
+	}
+
+	if flags["output"] == "" {
+		return projectRows(projects), nil
+	}
+	return projects, nil
+}
+
+type projectRow struct {
+	Name string `json:"name"`
+	Id   string `json:"projectId"`
+	Guid string `json:"guid"`
+}
+
+func projectRows(projects []responses.Project) []projectRow {
+	rows := make([]projectRow, 0, len(projects))
+	for _, proj := range projects {
+		rows = append(rows, projectRow{proj.Name, proj.Id, proj.Guid})
+	}
+	return rows
+}
+
+// filterProjects keeps the projects whose name or id contains filter, ignoring case
+func filterProjects(projects []responses.Project, filter string) []responses.Project {
+	if filter == "" {
+		return projects
+	}
+	filter = strings.ToLower(filter)
+
+	matching := make([]responses.Project, 0, len(projects))
+	for _, proj := range projects {
+		if strings.Contains(strings.ToLower(proj.Name), filter) || strings.Contains(strings.ToLower(proj.Id), filter) {
+			matching = append(matching, proj)
+		}
+	}
+	return matching
+}
+
+func sortProjects(projects []responses.Project, by string) error {
+	switch by {
+	case "", "name":
+		sort.SliceStable(projects, func(i, j int) bool {
+			return strings.ToLower(projects[i].Name) < strings.ToLower(projects[j].Name)
+		})
+	case "id":
+		sort.SliceStable(projects, func(i, j int) bool {
+			return projects[i].Id < projects[j].Id
+		})
+	default:
+		return validationErrorf("--sort: invalid column %q, must be name or id", by)
+	}
+	return nil
+}
+
+type projectReportRow struct {
+	Name         string `json:"name"`
+	Id           string `json:"projectId"`
+	BuildTargets int    `json:"buildTargets"`
+	Enabled      int    `json:"enabled"`
+	Error        string `json:"error,omitempty"`
+}
+
+// projectReport counts the build targets of each project and how many are enabled, fetching concurrency projects
+// at a time. A project whose targets couldn't be listed is reported with its error
+func projectReport(ctx context.Context, targetsService *cloudbuild.BuildTargetsService, projects []responses.Project, concurrency int) ([]projectReportRow, []cloudbuild.ProjectResult) {
+	ids := make([]string, 0, len(projects))
+	for _, proj := range projects {
+		ids = append(ids, proj.Id)
+	}
+
+	batch := cloudbuild.ForEachProject(ctx, ids, concurrency, func(ctx context.Context, projectId string) (interface{}, error) {
+		return targetsService.ListAllContext(ctx, projectId)
+	})
+
+	rows := make([]projectReportRow, 0, len(projects))
+	for i, result := range batch {
+		row := projectReportRow{Name: projects[i].Name, Id: projects[i].Id}
+		if result.Err != nil {
+			row.Error = result.Err.Error()
+		} else {
+			targets := result.Value.([]responses.BuildTarget)
+			row.BuildTargets = len(targets)
+			for _, target := range targets {
+				if target.Enabled {
+					row.Enabled++
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, batch
+}
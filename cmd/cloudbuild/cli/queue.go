@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/cmcpasserby/ucb/cmd/cloudbuild/settings"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	replaySent   = "sent"
+	replayFailed = "failed"
+	replayKept   = "kept"
+	replayQueued = "queued"
+)
+
+// queueMu serialises writes to the queue file from commands that change several things at once
+var queueMu sync.Mutex
+
+// queueRequest is the WithOfflineQueue callback used with --offline-queue
+func queueRequest(flags map[string]string) func(cloudbuild.QueuedRequest) error {
+	return func(req cloudbuild.QueuedRequest) error {
+		queueMu.Lock()
+		defer queueMu.Unlock()
+
+		if err := settings.AppendQueue(req); err != nil {
+			return fmt.Errorf("could not reach the api or queue the request: %v", err)
+		}
+
+		fmt.Fprintf(progress(flags), "queued %s %s, 'ucb flushQueue' sends it once the api can be reached\n", req.Method, req.URL)
+		return nil
+	}
+}
+
+type queueReplay struct {
+	Queued time.Time `json:"queued"`
+	Method string    `json:"method"`
+	URL    string    `json:"url"`
+	Result string    `json:"result"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// flushQueue sends the queued requests oldest first, a request the api answers is removed from the queue even when
+// it fails as sending it again won't help. The first request that still can't reach the api stops the flush and it
+// and the rest stay queued in order. Requests are sent with apiKey, the queue doesn't keep the key they were made
+// with. With list set nothing is sent
+func flushQueue(ctx context.Context, flags map[string]string, apiKey string, list bool) ([]queueReplay, error) {
+	entries, err := settings.ReadQueue()
+	if err != nil {
+		return nil, err
+	}
+
+	// a request failing again is kept where it is rather than queued a second time
+	delete(flags, "offline-queue")
+	opts := serviceOptions(flags)
+
+	replays := make([]queueReplay, len(entries))
+	var kept []json.RawMessage
+	failed := 0
+	stopped := list
+
+	for i, entry := range entries {
+		var req cloudbuild.QueuedRequest
+		if err := json.Unmarshal(entry, &req); err != nil {
+			replays[i] = queueReplay{Result: replayKept, Error: fmt.Sprintf("can't read the queued request: %v", err)}
+			kept = append(kept, entry)
+			continue
+		}
+
+		replay := queueReplay{Queued: req.Queued, Method: req.Method, URL: req.URL, Result: replayQueued}
+
+		if !stopped {
+			err := cloudbuild.Replay(ctx, apiKey, req, opts...)
+			_, unreachable := err.(*url.Error)
+
+			switch {
+			case err == nil:
+				replay.Result = replaySent
+			case err == cloudbuild.ErrDryRun:
+				replay.Result = replayKept
+			case unreachable || ctx.Err() != nil:
+				replay.Result, replay.Error = replayKept, err.Error()
+				stopped = true
+			default:
+				replay.Result, replay.Error = replayFailed, err.Error()
+				failed++
+			}
+		}
+
+		if replay.Result == replayKept || replay.Result == replayQueued {
+			kept = append(kept, entry)
+		}
+		replays[i] = replay
+	}
+
+	if !list {
+		queueMu.Lock()
+		err := settings.WriteQueue(kept)
+		queueMu.Unlock()
+		if err != nil {
+			return replays, err
+		}
+	}
+
+	switch {
+	case failed > 0:
+		return replays, fmt.Errorf("%d of %d queued requests failed", failed, len(entries))
+	case stopped && !list:
+		return replays, fmt.Errorf("could not reach the api, %d queued requests were kept", len(kept))
+	}
+	return replays, nil
+}
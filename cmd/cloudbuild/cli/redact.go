@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"encoding/json"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	secretsMu sync.Mutex
+	secrets   []string
+)
+
+// isSecretField reports if a struct field holds a value that must not be printed, either because it is tagged
+// type:"password" or its name is a known secret such as apiKey
+func isSecretField(field reflect.StructField) bool {
+	if field.Tag.Get("type") == "password" {
+		return true
+	}
+
+	for _, tag := range []string{"survey", "json"} {
+		if name := strings.Split(field.Tag.Get(tag), ",")[0]; name != "" && cloudbuild.IsSecretField(name) {
+			return true
+		}
+	}
+	return cloudbuild.IsSecretField(field.Name)
+}
+
+// registerSecrets remembers the secret values of a populated args struct so they can be masked by RedactSecrets
+func registerSecrets(data interface{}) {
+	v := reflect.Indirect(reflect.ValueOf(data))
+	tt := v.Type()
+
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	for i := 0; i < v.NumField(); i++ {
+		if val := v.Field(i).String(); val != "" && isSecretField(tt.Field(i)) {
+			secrets = append(secrets, val)
+		}
+	}
+}
+
+// RedactSecrets masks every api key and password the current command was given that appears in s
+func RedactSecrets(s string) string {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	for _, secret := range secrets {
+		s = strings.Replace(s, secret, cloudbuild.Redacted, -1)
+	}
+	return s
+}
+
+// secretJSONNames returns the json names of the secret fields in t and any types nested in it
+func secretJSONNames(t reflect.Type, names map[string]bool, seen map[reflect.Type]bool) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || seen[t] {
+		return
+	}
+	seen[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		if isSecretField(field) {
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "" {
+				name = field.Name
+			}
+			names[name] = true
+		}
+
+		secretJSONNames(field.Type, names, seen)
+	}
+}
+
+// redactData returns data with its secret fields masked, data is returned unchanged when its type has none
+func redactData(data interface{}) interface{} {
+	if data == nil {
+		return nil
+	}
+
+	names := make(map[string]bool)
+	secretJSONNames(reflect.TypeOf(data), names, make(map[reflect.Type]bool))
+	if len(names) == 0 {
+		return data
+	}
+
+	s, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(s, &generic); err != nil {
+		return data
+	}
+
+	return redactGeneric(generic, names)
+}
+
+func redactGeneric(v interface{}, names map[string]bool) interface{} {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for key, val := range x {
+			if names[key] {
+				x[key] = cloudbuild.Redacted
+			} else {
+				x[key] = redactGeneric(val, names)
+			}
+		}
+	case []interface{}:
+		for i, val := range x {
+			x[i] = redactGeneric(val, names)
+		}
+	}
+	return v
+}
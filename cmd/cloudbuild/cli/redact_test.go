@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPrettyPrintMasksSecrets(t *testing.T) {
+	data := struct {
+		Label    string `json:"label"`
+		CertPass string `json:"certPass" type:"password"`
+		ApiKey   string `json:"apiKey"`
+	}{"release", "hunter2", testApiKey}
+
+	for _, format := range outputFormats {
+		var out bytes.Buffer
+		if err := prettyPrint(&out, format, "", data); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+
+		printed := out.String()
+		if strings.Contains(printed, "hunter2") || strings.Contains(printed, testApiKey) {
+			t.Errorf("%s output leaks a secret:\n%s", format, printed)
+		}
+		if !strings.Contains(printed, "****") || !strings.Contains(printed, "release") {
+			t.Errorf("%s output doesn't show the masked fields:\n%s", format, printed)
+		}
+	}
+}
+
+func TestRedactSecretsInErrors(t *testing.T) {
+	registerSecrets(&struct {
+		CertPass string `survey:"certPass" type:"password"`
+	}{"s3cret-pass"})
+
+	err := errors.New("could not read cert with password s3cret-pass")
+	if got, want := RedactSecrets(err.Error()), "could not read cert with password ****"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"github.com/cmcpasserby/ucb/cmd/cloudbuild/settings"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
+	"sort"
+	"strings"
+)
+
+// defaultRegion is served by the api the services use without WithBaseURL
+const defaultRegion = "us"
+
+// regionURLs returns the api url of every known region, the config file's regions table adds to and overrides the
+// built in ones. An empty url is the services' default api
+func regionURLs() map[string]string {
+	urls := map[string]string{defaultRegion: ""}
+	if data, err := settings.ParseDotFile(); err == nil {
+		for name, u := range data.Regions {
+			urls[strings.ToLower(name)] = u
+		}
+	}
+	return urls
+}
+
+func regionNames(urls map[string]string) []string {
+	names := make([]string, 0, len(urls))
+	for name := range urls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyRegion sets the api url for the region from --region, UCB_REGION or the config file, an --api-url or
+// UCB_API_URL is used as given instead. The region in use is left in flagMap for RegionHint
+func applyRegion(flagMap map[string]string) error {
+	region := strings.ToLower(flagMap["region"])
+	if region == "" {
+		configured, err := settings.GetRegion(flagMap["profile"])
+		if _, notFound := err.(*settings.ProfileNotFoundError); notFound {
+			// only config --setup gets this far with a new profile
+			configured, err = settings.GetRegion("")
+		}
+		if err != nil {
+			return err
+		}
+		region = strings.ToLower(configured)
+	}
+	if region == "" {
+		region = defaultRegion
+	}
+	flagMap["region"] = region
+
+	urls := regionURLs()
+	u, ok := urls[region]
+	if !ok {
+		return validationErrorf("--region: unknown region %q, known regions are %s, others need their api url under regions in the config file", region, strings.Join(regionNames(urls), ", "))
+	}
+
+	if _, given := flagMap["api-url"]; given || u == "" {
+		return nil
+	}
+
+	if err := validateAPIURL(u); err != nil {
+		return validationErrorf("region %s: %v", region, err)
+	}
+	flagMap["api-url"] = u
+	return nil
+}
+
+// RegionHint explains a not found error caused by the org being in another region than the one in use, it is
+// empty unless the org itself can't be found there
+func RegionHint(ctx context.Context, flags map[string]string, err error) string {
+	if _, ok := err.(*cloudbuild.NotFoundError); !ok || flags["orgId"] == "" || flags["apiKey"] == "" {
+		return ""
+	}
+
+	orgService := cloudbuild.NewOrgService(flags["apiKey"], flags["orgId"], serviceOptions(flags)...)
+	if _, err := orgService.NameContext(ctx); err == nil {
+		return ""
+	} else if _, ok := err.(*cloudbuild.NotFoundError); !ok {
+		return ""
+	}
+
+	return fmt.Sprintf("org %s wasn't found in the %s region, if its data is kept in another region select it with --region or region in the config file (known regions: %s)",
+		flags["orgId"], flags["region"], strings.Join(regionNames(regionURLs()), ", "))
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const reachableTimeout = 10 * time.Second
+
+var (
+	tempMu  sync.Mutex
+	tempDir string
+)
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+// localOrRemoteFile validates a file path flag that may also be an http(s) url, local paths must exist and pass
+// check, urls only need to be reachable as check is run once they are downloaded
+func localOrRemoteFile(check func(path string) error) func(v interface{}) error {
+	return func(v interface{}) error {
+		str, ok := v.(string)
+		if ok && isURL(str) {
+			return checkReachable(str)
+		}
+
+		if err := fileExists(v); err != nil {
+			return err
+		}
+		if check == nil {
+			return nil
+		}
+		return check(normalizePath(str))
+	}
+}
+
+func checkReachable(u string) error {
+	client := http.Client{Timeout: reachableTimeout}
+
+	resp, err := client.Head(u)
+	if err != nil {
+		return fmt.Errorf("unreachable url: %v", err)
+	}
+	resp.Body.Close()
+
+	// some object stores only sign urls for GET, the download reports any real problem
+	if resp.StatusCode >= 400 && resp.StatusCode != http.StatusMethodNotAllowed && resp.StatusCode != http.StatusForbidden {
+		return fmt.Errorf("unreachable url: %s", resp.Status)
+	}
+	return nil
+}
+
+// downloadTemp downloads u to a temp file that keeps the url's file extension, the file is removed by Cleanup and
+// straight away if the download fails or is cancelled
+func downloadTemp(ctx context.Context, u string) (name string, err error) {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("downloading %s: %s", parsed.Path, resp.Status)
+	}
+
+	f, err := createTemp("ucb-*" + path.Ext(parsed.Path))
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	_, err = io.Copy(f, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// createTemp creates a file in the temp directory of this run, which is made on first use
+func createTemp(pattern string) (*os.File, error) {
+	tempMu.Lock()
+	defer tempMu.Unlock()
+
+	if tempDir == "" {
+		dir, err := ioutil.TempDir("", "ucb-")
+		if err != nil {
+			return nil, err
+		}
+		tempDir = dir
+	}
+
+	return ioutil.TempFile(tempDir, pattern)
+}
+
+// Cleanup removes the temp files created while running a command, it is safe to call more than once and from a
+// signal handler while a download is in progress
+func Cleanup() {
+	tempMu.Lock()
+	defer tempMu.Unlock()
+
+	if tempDir != "" {
+		os.RemoveAll(tempDir)
+		tempDir = ""
+	}
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDownloadTempCancelled(t *testing.T) {
+	tmp := t.TempDir()
+	setenv(t, "TMPDIR", tmp)
+	defer Cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// half of the cert is sent then the download is cancelled once its temp file exists
+	api := newFakeApi(t)
+	api.handle("GET /dist.p12", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "2048")
+		w.Write(make([]byte, 1024))
+		w.(http.Flusher).Flush()
+
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+			if files, _ := filepath.Glob(filepath.Join(tmp, "ucb-*", "*.p12")); len(files) > 0 {
+				break
+			}
+		}
+		cancel()
+		<-r.Context().Done()
+	})
+
+	if _, err := downloadTemp(ctx, api.URL+"/dist.p12"); err == nil {
+		t.Fatal("the cancelled download succeeded")
+	}
+
+	files, _ := filepath.Glob(filepath.Join(tmp, "ucb-*", "*"))
+	if len(files) > 0 {
+		t.Errorf("the cancelled download left %v", files)
+	}
+
+	Cleanup()
+	if entries, err := ioutil.ReadDir(tmp); err != nil || len(entries) > 0 {
+		t.Errorf("the temp dir isn't empty after Cleanup: %v %v", entries, err)
+	}
+}
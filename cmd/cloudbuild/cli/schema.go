@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// jsonSchema is the subset of JSON Schema the bundled schemas use
+type jsonSchema struct {
+	Type                 string                 `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Enum                 []interface{}          `json:"enum"`
+	Items                *jsonSchema            `json:"items"`
+}
+
+// schemaError is a problem with the value at Path, Line is where it is in the document or 0 if unknown
+type schemaError struct {
+	Path string
+	Line int
+	Msg  string
+}
+
+func (e schemaError) String() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", e.Line, e.Path, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+}
+
+// validateSchema checks data, a json document, against schema returning every problem found
+func validateSchema(schema []byte, data []byte) ([]schemaError, error) {
+	var s jsonSchema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			return []schemaError{{"(document)", lineAt(data, syntaxErr.Offset), syntaxErr.Error()}}, nil
+		}
+		return nil, err
+	}
+
+	var problems []schemaError
+	s.validate("", doc, &problems)
+
+	offsets := keyOffsets(data)
+	for i := range problems {
+		problems[i].Line = lineAt(data, offsets[problems[i].Path])
+		if problems[i].Path == "" {
+			problems[i].Path = "(document)"
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+	return problems, nil
+}
+
+func (s *jsonSchema) validate(path string, v interface{}, problems *[]schemaError) {
+	if s.Type != "" && !hasSchemaType(s.Type, v) {
+		*problems = append(*problems, schemaError{Path: path, Msg: fmt.Sprintf("must be a %s", s.Type)})
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		options := make([]string, 0, len(s.Enum))
+		for _, option := range s.Enum {
+			if option == v {
+				found = true
+			}
+			options = append(options, fmt.Sprint(option))
+		}
+		if !found {
+			*problems = append(*problems, schemaError{Path: path, Msg: fmt.Sprintf("%v is not one of: %s", v, strings.Join(options, ", "))})
+		}
+	}
+
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := x[name]; !ok {
+				*problems = append(*problems, schemaError{Path: path, Msg: fmt.Sprintf("missing required field %q", name)})
+			}
+		}
+
+		keys := make([]string, 0, len(x))
+		for key := range x {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			keyPath := joinSchemaPath(path, key)
+			if prop, ok := s.Properties[key]; ok {
+				prop.validate(keyPath, x[key], problems)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*problems = append(*problems, schemaError{Path: keyPath, Msg: "unknown field" + suggestField(key, s.Properties)})
+			}
+		}
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range x {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, problems)
+			}
+		}
+	}
+}
+
+func hasSchemaType(t string, v interface{}) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == float64(int64(f))
+	case "null":
+		return v == nil
+	}
+	return true
+}
+
+// suggestField names the known field key is most likely a typo of, eg platform for platfrom
+func suggestField(key string, properties map[string]*jsonSchema) string {
+	best, bestDist := "", len(key)/2+1
+	for name := range properties {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(name)); d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+// editDistance is the optimal string alignment distance, a transposition like platfrom counts as one edit
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, minInt(d[i][j-1]+1, d[i-1][j-1]+cost))
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func joinSchemaPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// keyOffsets maps the path of every object key in data to the offset just after it
+func keyOffsets(data []byte) map[string]int64 {
+	offsets := make(map[string]int64)
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	var walk func(path string) error
+	walk = func(path string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+
+				keyPath := joinSchemaPath(path, fmt.Sprint(key))
+				offsets[keyPath] = dec.InputOffset()
+				if err := walk(keyPath); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+			return err
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+			return err
+		}
+		return nil
+	}
+
+	walk("")
+	return offsets
+}
+
+// lineAt returns the 1 based line of offset in data, or 0 for an unknown offset
+func lineAt(data []byte, offset int64) int {
+	if offset <= 0 || offset > int64(len(data)) {
+		return 0
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"fmt"
+	"golang.org/x/crypto/ssh/terminal"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// passwordFromFlags reads a password given via --<name>-file, or --<name> - for stdin,
+// found is false when neither is used so the normal flag or prompt handling applies
+func passwordFromFlags(flags map[string]string, name string) (secret string, found bool, err error) {
+	if path, ok := flags[name+"-file"]; ok {
+		secret, err = readSecret(name, path)
+		return secret, true, err
+	}
+
+	if flags[name] == "-" {
+		secret, err = readSecret(name, "-")
+		return secret, true, err
+	}
+
+	return "", false, nil
+}
+
+// readSecret reads a secret from path, or from stdin when path is - without echoing it on a terminal
+func readSecret(name, path string) (string, error) {
+	var data []byte
+	var err error
+
+	if path == "-" {
+		if fd := int(os.Stdin.Fd()); terminal.IsTerminal(fd) {
+			fmt.Fprintf(os.Stderr, "%s: ", name)
+			data, err = terminal.ReadPassword(fd)
+			fmt.Fprintln(os.Stderr)
+		} else {
+			data, err = ioutil.ReadAll(os.Stdin)
+		}
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %v", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
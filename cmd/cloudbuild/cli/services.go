@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+func validateLogFormat(format string) error {
+	if format == "" || format == logFormatText || format == logFormatJSON {
+		return nil
+	}
+	return validationErrorf("invalid log format %q, must be one of: %s, %s", format, logFormatText, logFormatJSON)
+}
+
+// validateAPIURL requires an https url, plain http is allowed for a mock server on the local machine
+func validateAPIURL(val string) error {
+	u, err := url.Parse(val)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid url %q", val)
+	}
+
+	switch host := u.Hostname(); {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && (host == "localhost" || net.ParseIP(host).IsLoopback()):
+	default:
+		return fmt.Errorf("%q must be an https url", val)
+	}
+
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("%q can't have a query or fragment", val)
+	}
+	return nil
+}
+
+// serviceOptions converts the global flags into options for the cloudbuild services
+func serviceOptions(flags map[string]string) []cloudbuild.Option {
+	var opts []cloudbuild.Option
+
+	// ParseFlags has already validated the request timeout
+	if val, ok := flags["request-timeout"]; ok {
+		timeout, _ := parseDuration(val)
+		opts = append(opts, cloudbuild.WithTimeout(timeout))
+	}
+
+	if flags["log-format"] == logFormatJSON {
+		opts = append(opts, cloudbuild.WithRequestLogger(cloudbuild.NewJSONLogger(os.Stderr)))
+	} else if boolFlag(flags, "verbose") {
+		opts = append(opts, cloudbuild.WithLogger(log.New(os.Stderr, "", log.LstdFlags)))
+	}
+
+	// ParseFlags has already validated the proxy url
+	if val := flags["proxy"]; val != "" {
+		proxy, _ := url.Parse(val)
+		opts = append(opts, cloudbuild.WithProxy(proxy))
+	}
+
+	// ParseFlags has already validated the api url
+	if val := flags["api-url"]; val != "" {
+		baseUrl, _ := url.Parse(val)
+		if !strings.HasSuffix(baseUrl.Path, "/") {
+			// keep any path prefix when the api paths are resolved against it
+			baseUrl.Path += "/"
+		}
+		opts = append(opts, cloudbuild.WithBaseURL(baseUrl))
+	}
+
+	// ParseFlags has already validated the page size
+	if val, ok := flags["page-size"]; ok {
+		pageSize, _ := strconv.Atoi(val)
+		opts = append(opts, cloudbuild.WithPageSize(pageSize))
+	}
+
+	if boolFlag(flags, "raw") && !boolFlag(flags, "quiet") {
+		opts = append(opts, cloudbuild.WithRawResponses(os.Stdout))
+	}
+
+	if boolFlag(flags, "offline-queue") {
+		opts = append(opts, cloudbuild.WithOfflineQueue(queueRequest(flags)))
+	}
+
+	if boolFlag(flags, "dry-run") {
+		opts = append(opts, cloudbuild.WithDryRun(os.Stdout))
+	}
+
+	return opts
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestProxyFlag(t *testing.T) {
+	// --proxy overrides the environment's proxy, which nothing is listening on
+	setenv(t, "HTTPS_PROXY", "http://127.0.0.1:1")
+	setenv(t, "HTTP_PROXY", "http://127.0.0.1:1")
+
+	// the proxy is sent requests for the api's url, which doesn't resolve, so they only succeed through it
+	proxy := newFakeApi(t)
+	proxy.handle("GET /api/v1/orgs/example/projects", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Host != "build-api.invalid" {
+			t.Errorf("proxy got a request for %s", r.URL)
+		}
+		fmt.Fprint(w, `[{"projectId":"my-game"}]`)
+	})
+
+	flags := commandFlags(t, proxy, map[string]string{"proxy": proxy.URL})
+	flags["api-url"] = "http://build-api.invalid/"
+
+	if err := runCommand("listProjects", flags); err != nil {
+		t.Fatal(err)
+	}
+	if !proxy.received("GET /api/v1/orgs/example/projects") {
+		t.Errorf("the projects weren't requested through --proxy, got %v", proxy.requests)
+	}
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild/responses"
+	"strings"
+	"time"
+)
+
+const defaultExpiryWindow = 30 * 24 * time.Hour
+
+// checkSigningExpiry fails when certPass does not decrypt the certificate and warns when the certificate or
+// provisioning profile has expired or expires within --expiry-window, with --strict these become an error
+func checkSigningExpiry(flags map[string]string, certPath, certPass, profilePath string) error {
+	window := defaultExpiryWindow
+	if val := flags["expiry-window"]; val != "" {
+		d, err := parseDuration(val)
+		if err != nil {
+			return validationErrorf("--expiry-window: %v", err)
+		}
+		window = d
+	}
+
+	now := time.Now()
+	var problems []string
+
+	if profile, err := cloudbuild.ReadProvisioningProfile(profilePath); err == nil {
+		if problem := expiryProblem("provisioning profile", profile.ExpirationDate, now, window); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+
+	if cert, err := cloudbuild.ReadP12Certificate(certPath, certPass); err == nil {
+		if problem := expiryProblem("certificate", cert.NotAfter, now, window); problem != "" {
+			problems = append(problems, problem)
+		}
+	} else if err == cloudbuild.ErrIncorrectP12Password {
+		return validationErrorf("--certPass: %v for %s", err, certPath)
+	} else {
+		// formats the pkcs12 package doesn't support are left for the api to check
+		fmt.Fprintf(progress(flags), "warning: could not read certificate expiry: %v\n", err)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	if boolFlag(flags, "strict") {
+		return &ValidationError{errors.New(strings.Join(problems, ", "))}
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintf(progress(flags), "warning: %s\n", problem)
+	}
+	return nil
+}
+
+func expiryProblem(name string, expiry, now time.Time, window time.Duration) string {
+	if expiry.IsZero() {
+		return ""
+	}
+
+	if expiry.Before(now) {
+		return fmt.Sprintf("%s expired on %s", name, expiry.Format("2006-01-02"))
+	}
+
+	if expiry.Before(now.Add(window)) {
+		return fmt.Sprintf("%s expires on %s", name, expiry.Format("2006-01-02"))
+	}
+	return ""
+}
+
+// expirySlack is how far apart an uploaded and a local expiry may be and still count as the same, the api doesn't
+// always return them to the second
+const expirySlack = time.Minute
+
+const (
+	diffSame    = "same"
+	diffChanged = "changed"
+	diffUnknown = "unknown" // the api didn't return the value to compare with
+)
+
+type credDiff struct {
+	Field    string `json:"field"`
+	Uploaded string `json:"uploaded"`
+	Local    string `json:"local"`
+	Result   string `json:"result"`
+}
+
+// diffIOSCred compares the certificate and profile files with what the api reports about an uploaded credential
+func diffIOSCred(cred *responses.IOSCred, certPath, certPass, profilePath string) ([]credDiff, error) {
+	cert, err := cloudbuild.ReadP12Certificate(certPath, certPass)
+	if err == cloudbuild.ErrIncorrectP12Password {
+		return nil, validationErrorf("--certPass: %v for %s", err, certPath)
+	} else if err != nil {
+		return nil, fmt.Errorf("%s: %v", certPath, err)
+	}
+
+	profile, err := cloudbuild.ReadProvisioningProfile(profilePath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", profilePath, err)
+	}
+
+	var teamId string
+	if len(cert.Subject.OrganizationalUnit) > 0 {
+		teamId = cert.Subject.OrganizationalUnit[0]
+	}
+
+	return []credDiff{
+		diffString("certificate name", cred.Certificate.Name, cert.Subject.CommonName),
+		diffString("certificate team", cred.Certificate.TeamId, teamId),
+		diffTime("certificate expiration", cred.Certificate.Expiration, cert.NotAfter),
+		diffString("profile uuid", cred.ProvisioningProfile.UUID, profile.UUID),
+		diffTime("profile expiration", cred.ProvisioningProfile.Expiration, profile.ExpirationDate),
+	}, nil
+}
+
+func diffString(field, uploaded, local string) credDiff {
+	result := diffChanged
+	switch {
+	case uploaded == "":
+		result = diffUnknown
+	case uploaded == local:
+		result = diffSame
+	}
+	return credDiff{field, uploaded, local, result}
+}
+
+func diffTime(field string, uploaded, local time.Time) credDiff {
+	result := diffChanged
+	switch {
+	case uploaded.IsZero():
+		result = diffUnknown
+	case uploaded.Sub(local) < expirySlack && local.Sub(uploaded) < expirySlack:
+		result = diffSame
+	}
+
+	return credDiff{field, formatExpiry(uploaded), formatExpiry(local), result}
+}
+
+func formatExpiry(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// credChanged reports if any compared value differs, values the api didn't return don't count
+func credChanged(diffs []credDiff) bool {
+	for _, diff := range diffs {
+		if diff.Result == diffChanged {
+			return true
+		}
+	}
+	return false
+}
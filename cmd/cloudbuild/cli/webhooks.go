@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"errors"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild/responses"
+	"net/url"
+	"strings"
+)
+
+func validHookURL(v interface{}) error {
+	dataErr := errors.New("invalid url, must be a https url such as https://hooks.example.com/build")
+
+	str, ok := v.(string)
+	if !ok {
+		return dataErr
+	}
+
+	u, err := url.Parse(str)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return dataErr
+	}
+	return nil
+}
+
+// parseHookEvents reads a comma separated list of webhook events, an empty list selects every event
+func parseHookEvents(s string) ([]responses.WebhookEvent, error) {
+	if strings.TrimSpace(s) == "" {
+		return responses.WebhookEvents, nil
+	}
+
+	var events []responses.WebhookEvent
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+
+		found := false
+		for _, event := range responses.WebhookEvents {
+			if strings.EqualFold(name, string(event)) {
+				events = append(events, event)
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			valid := make([]string, 0, len(responses.WebhookEvents))
+			for _, event := range responses.WebhookEvents {
+				valid = append(valid, string(event))
+			}
+			return nil, validationErrorf("--events: unknown event %q, must be one of: %s", name, strings.Join(valid, ", "))
+		}
+	}
+	return events, nil
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/cmcpasserby/ucb/cmd/cloudbuild/cli"
+	"github.com/cmcpasserby/ucb/cmd/cloudbuild/settings"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 )
 
 func main() {
@@ -14,15 +20,45 @@ func main() {
 		return
 	}
 
+	if os.Args[1] == "help" || os.Args[1] == "--help" || os.Args[1] == "-h" {
+		if len(os.Args) < 3 {
+			printHelp()
+			return
+		}
+
+		if val, ok := cli.Commands[os.Args[2]]; ok {
+			cli.PrintCommandHelp(os.Stdout, val)
+			return
+		}
+
+		fmt.Printf("%q is not a valid command\n", os.Args[2])
+		fmt.Println()
+		printHelp()
+		os.Exit(exitValidation)
+	}
+
 	if val, ok := cli.Commands[os.Args[1]]; ok {
+		val.Flags.Usage = func() {
+			cli.PrintCommandHelp(os.Stderr, val)
+		}
+
 		flagsMap, err := cli.ParseFlags(val.Flags, os.Args[2:])
-		if err != nil {
-			log.Fatal(err)
+		if err == flag.ErrHelp {
+			return
+		} else if err != nil {
+			fatal(err)
 		}
 
-		err = val.Action(flagsMap)
-		if err != nil {
-			log.Fatal(err)
+		ctx, cancel := interruptContext()
+		defer cancel()
+		defer cli.Cleanup()
+
+		err = val.Action(ctx, flagsMap)
+		cli.Cleanup() // fatal exits without running deferred calls
+		if err == cloudbuild.ErrDryRun {
+			return
+		} else if err != nil {
+			fatal(err, cli.RegionHint(ctx, flagsMap, err))
 		}
 	} else {
 		fmt.Printf("%q is not a valid command\n", os.Args[1])
@@ -31,51 +67,127 @@ func main() {
 	}
 }
 
+const (
+	exitError        = 1
+	exitAuth         = 2
+	exitNotFound     = 3
+	exitValidation   = 4
+	exitBuildFailed  = 5
+	exitBuildRunning = 6
+	exitInterrupted  = 130
+)
+
+// exitCodes documents each exit code in the order printHelp lists them
+var exitCodes = []struct {
+	code int
+	desc string
+}{
+	{0, "success"},
+	{exitError, "general failure"},
+	{exitAuth, "authentication failure, the api key or org id was rejected (401/403)"},
+	{exitNotFound, "not found (404)"},
+	{exitValidation, "invalid or missing input"},
+	{exitBuildFailed, "a waited on build failed or was canceled"},
+	{exitBuildRunning, "a checked build hasn't finished yet"},
+	{exitInterrupted, "interrupted a second time before the command could stop"},
+}
+
+// fatal logs err and any non empty hints explaining it, then exits with err's exit code
+func fatal(err error, hints ...string) {
+	log.Println(cli.ColorError(cli.RedactSecrets(err.Error())))
+	for _, hint := range hints {
+		if hint != "" {
+			log.Println(hint)
+		}
+	}
+	os.Exit(exitCode(err))
+}
+
+func exitCode(err error) int {
+	switch err.(type) {
+	case *cloudbuild.AuthError:
+		return exitAuth
+	case *cloudbuild.NotFoundError:
+		return exitNotFound
+	case *cli.ValidationError, *settings.ProfileNotFoundError, *settings.AliasNotFoundError, *settings.ConfigError:
+		return exitValidation
+	case *cli.BuildFailedError:
+		return exitBuildFailed
+	case *cli.BuildRunningError:
+		return exitBuildRunning
+	default:
+		return exitError
+	}
+}
+
+// interruptContext returns a context that is cancelled when the process receives an interrupt or SIGTERM, a second
+// signal removes any temp files and exits straight away
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		select {
+		case <-sigs:
+			cancel()
+		case <-ctx.Done():
+			signal.Stop(sigs)
+			return
+		}
+
+		<-sigs
+		cli.Cleanup()
+		os.Exit(exitInterrupted)
+	}()
+
+	return ctx, cancel
+}
+
 func printHelp() {
-	fmt.Println(
+	fmt.Print(
 		`Tool for working with Unity Cloud Build
 
 usage:
   ucb <command> [flags]
-  Global Flags: --apiKey, --orgId (these are best defined in the config file via 'ucb config')
+  ucb help <command> (describe a command's flags with examples)
+`)
 
-commands are:`)
+	cli.WriteFlags(os.Stdout, "global flags", cli.GlobalFlags())
 
+	fmt.Println("\ncommands are:")
+
+	metas := make([]cli.CommandMeta, 0, len(cli.CommandOrder))
 	maxNameLen := 0
 	maxDescLen := 0
 
 	for _, key := range cli.CommandOrder {
-		cmd := cli.Commands[key]
-		if len(cmd.Name) > maxNameLen {
-			maxNameLen = len(cmd.Name)
+		meta := cli.Commands[key].Meta
+		metas = append(metas, meta)
+
+		if len(meta.Name) > maxNameLen {
+			maxNameLen = len(meta.Name)
 		}
 
-		if len(cmd.HelpText) > maxDescLen {
-			maxDescLen = len(cmd.HelpText)
+		if len(meta.Summary) > maxDescLen {
+			maxDescLen = len(meta.Summary)
 		}
 	}
 	maxNameLen += 2
 	maxDescLen += 2
 
-	for _, key := range cli.CommandOrder {
-		cmd := cli.Commands[key]
-		fmt.Printf("  %-*s%-*sflags: [", maxNameLen, cmd.Name, maxDescLen, cmd.HelpText)
-
-		hasFlags := false
-
-		cmd.Flags.VisitAll(func(flag *flag.Flag) {
-			if flag.Name != "apiKey" && flag.Name != "orgId" {
-				fmt.Printf("--%s, ", flag.Name)
-				hasFlags = true
-			}
-		})
-
-		if hasFlags {
-			fmt.Printf("\033[2D]")
-		} else {
-			fmt.Printf("]")
+	for _, meta := range metas {
+		names := make([]string, 0, len(meta.Flags))
+		for _, f := range meta.CommandFlags() {
+			names = append(names, "--"+f.Name)
 		}
 
-		fmt.Println()
+		fmt.Printf("  %-*s%-*sflags: [%s]\n", maxNameLen, meta.Name, maxDescLen, meta.Summary, strings.Join(names, ", "))
+	}
+
+	fmt.Println("\nexit codes are:")
+	for _, exit := range exitCodes {
+		fmt.Printf("  %-5d%s\n", exit.code, exit.desc)
 	}
 }
@@ -0,0 +1,95 @@
+package settings
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AliasNotFoundError is returned when a requested alias is not in the settings
+type AliasNotFoundError struct {
+	Name      string
+	Available []string
+}
+
+func (e *AliasNotFoundError) Error() string {
+	if len(e.Available) == 0 {
+		return fmt.Sprintf("alias %q not found, no aliases are defined", e.Name)
+	}
+	return fmt.Sprintf("alias %q not found, available aliases: %s", e.Name, strings.Join(e.Available, ", "))
+}
+
+// AliasNames returns the sorted names of the aliases defined in the settings
+func (s *CliSettings) AliasNames() []string {
+	names := make([]string, 0, len(s.Aliases))
+	for name := range s.Aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetAlias returns the project and build target an alias refers to, a leading @ is ignored
+func GetAlias(name string) (Alias, error) {
+	data, err := ParseDotFile()
+	if err != nil {
+		return Alias{}, err
+	}
+
+	name = strings.TrimPrefix(name, "@")
+	alias, ok := data.Aliases[name]
+	if !ok {
+		return Alias{}, &AliasNotFoundError{name, data.AliasNames()}
+	}
+	return alias, nil
+}
+
+// GetAliases returns every alias defined in the settings
+func GetAliases() (map[string]Alias, error) {
+	data, err := ParseDotFile()
+	if err != nil {
+		return nil, err
+	}
+	return data.Aliases, nil
+}
+
+// SetAlias stores an alias in the dot file, replacing any alias of the same name
+func SetAlias(name string, alias Alias) error {
+	dotPath, err := GetFilePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := ParseDotFile()
+	if err != nil {
+		return err
+	}
+
+	if data.Aliases == nil {
+		data.Aliases = make(map[string]Alias)
+	}
+	data.Aliases[strings.TrimPrefix(name, "@")] = alias
+
+	return writeDotFile(dotPath, data)
+}
+
+// DeleteAlias removes an alias from the dot file
+func DeleteAlias(name string) error {
+	dotPath, err := GetFilePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := ParseDotFile()
+	if err != nil {
+		return err
+	}
+
+	name = strings.TrimPrefix(name, "@")
+	if _, ok := data.Aliases[name]; !ok {
+		return &AliasNotFoundError{name, data.AliasNames()}
+	}
+	delete(data.Aliases, name)
+
+	return writeDotFile(dotPath, data)
+}
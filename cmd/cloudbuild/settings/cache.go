@@ -0,0 +1,89 @@
+package settings
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+)
+
+const cacheFileName string = ".cloudbuild-cache"
+
+type cacheEntry struct {
+	Stored time.Time       `json:"stored"`
+	Data   json.RawMessage `json:"data"`
+}
+
+func getCachePath() (string, error) {
+	dotPath, err := GetFilePath()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(path.Dir(dotPath), cacheFileName), nil
+}
+
+func readCacheFile(cachePath string) (map[string]cacheEntry, error) {
+	entries := make(map[string]cacheEntry)
+
+	data, err := ioutil.ReadFile(cachePath)
+	if os.IsNotExist(err) {
+		return entries, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// a corrupt cache is treated as empty, it is rewritten on the next store
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return make(map[string]cacheEntry), nil
+	}
+	return entries, nil
+}
+
+// ReadCache decodes the value cached under key into v, ok is false if there is no entry younger than ttl
+func ReadCache(key string, ttl time.Duration, v interface{}) (ok bool, err error) {
+	cachePath, err := getCachePath()
+	if err != nil {
+		return false, err
+	}
+
+	entries, err := readCacheFile(cachePath)
+	if err != nil {
+		return false, err
+	}
+
+	entry, ok := entries[key]
+	if !ok || time.Since(entry.Stored) > ttl {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(entry.Data, v); err != nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+// WriteCache stores v under key, replacing any previous entry
+func WriteCache(key string, v interface{}) error {
+	cachePath, err := getCachePath()
+	if err != nil {
+		return err
+	}
+
+	entries, err := readCacheFile(cachePath)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	entries[key] = cacheEntry{time.Now(), data}
+
+	out, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(cachePath, out, 0600)
+}
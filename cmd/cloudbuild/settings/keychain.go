@@ -0,0 +1,20 @@
+package settings
+
+import "errors"
+
+// keychainService is the service name the api keys are stored under in the OS keychain
+const keychainService = "ucb"
+
+// defaultAccount is the keychain account of the api key used when no profile is given
+const defaultAccount = "default"
+
+// ErrKeychainUnavailable is returned when the OS has no keychain the settings can use
+var ErrKeychainUnavailable = errors.New("no keychain available")
+
+// keychainAccount returns the keychain account the api key of profile is stored under
+func keychainAccount(profile string) string {
+	if profile == "" {
+		return defaultAccount
+	}
+	return profile
+}
@@ -0,0 +1,36 @@
+package settings
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// keychainGet reads a secret from the macOS login Keychain
+func keychainGet(account string) (string, error) {
+	if _, err := exec.LookPath("security"); err != nil {
+		return "", ErrKeychainUnavailable
+	}
+
+	out, err := exec.Command("security", "find-generic-password", "-s", keychainService, "-a", account, "-w").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// keychainSet stores a secret in the macOS login Keychain, replacing any existing one. The command is given on
+// stdin so the secret doesn't show up in the process list
+func keychainSet(account, secret string) error {
+	if _, err := exec.LookPath("security"); err != nil {
+		return ErrKeychainUnavailable
+	}
+
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader("add-generic-password -U -s " + quote(keychainService) + " -a " + quote(account) + " -w " + quote(secret) + "\n")
+	return cmd.Run()
+}
+
+// quote escapes s for the security tool's interactive mode
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
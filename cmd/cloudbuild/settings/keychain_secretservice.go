@@ -0,0 +1,34 @@
+//go:build !darwin && !windows
+// +build !darwin,!windows
+
+package settings
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// keychainGet reads a secret from the freedesktop secret service, eg GNOME Keyring, through libsecret's secret-tool
+func keychainGet(account string) (string, error) {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return "", ErrKeychainUnavailable
+	}
+
+	out, err := exec.Command("secret-tool", "lookup", "service", keychainService, "account", account).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// keychainSet stores a secret in the freedesktop secret service, replacing any existing one. The secret is given
+// on stdin so it doesn't show up in the process list
+func keychainSet(account, secret string) error {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return ErrKeychainUnavailable
+	}
+
+	cmd := exec.Command("secret-tool", "store", "--label=ucb api key ("+account+")", "service", keychainService, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	return cmd.Run()
+}
@@ -0,0 +1,95 @@
+package settings
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+)
+
+var (
+	advapi32      = syscall.NewLazyDLL("advapi32.dll")
+	procCredRead  = advapi32.NewProc("CredReadW")
+	procCredWrite = advapi32.NewProc("CredWriteW")
+	procCredFree  = advapi32.NewProc("CredFree")
+)
+
+// credential mirrors the win32 CREDENTIALW struct
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+func credTarget(account string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(keychainService + ":" + account)
+}
+
+// keychainGet reads a secret from the Windows Credential Manager
+func keychainGet(account string) (string, error) {
+	if procCredRead.Find() != nil {
+		return "", ErrKeychainUnavailable
+	}
+
+	target, err := credTarget(account)
+	if err != nil {
+		return "", err
+	}
+
+	var cred *credential
+	if ret, _, err := procCredRead.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred))); ret == 0 {
+		return "", err
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	blob := make([]byte, cred.CredentialBlobSize)
+	if cred.CredentialBlobSize > 0 {
+		copy(blob, (*[1 << 20]byte)(unsafe.Pointer(cred.CredentialBlob))[:cred.CredentialBlobSize:cred.CredentialBlobSize])
+	}
+	return string(blob), nil
+}
+
+// keychainSet stores a secret in the Windows Credential Manager, replacing any existing one
+func keychainSet(account, secret string) error {
+	if procCredWrite.Find() != nil {
+		return ErrKeychainUnavailable
+	}
+
+	target, err := credTarget(account)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(account)
+	if err != nil {
+		return err
+	}
+
+	blob := []byte(secret)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+
+	if ret, _, err := procCredWrite.Call(uintptr(unsafe.Pointer(&cred)), 0); ret == 0 {
+		return err
+	}
+	return nil
+}
@@ -1,17 +1,133 @@
 package settings
 
 import (
+	"fmt"
 	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
 	"os"
 	"os/user"
 	"path"
+	"sort"
+	"strings"
 )
 
 const dotFileName string = ".cloudbuild"
 
+// filePath overrides the default dot file location when set
+var filePath string
+
+// CliSettings is the schema of the dot file, it is toml unless the file has a .yaml or .yml extension
 type CliSettings struct {
-	ApiKey string `toml:"apiKey"`
-	OrgId  string `toml:"orgId"`
+	ApiKey   string             `toml:"apiKey" yaml:"apiKey"`
+	OrgId    string             `toml:"orgId" yaml:"orgId"`
+	Profiles map[string]Profile `toml:"profiles" yaml:"profiles,omitempty"`
+	Defaults Defaults           `toml:"defaults,omitempty" yaml:"defaults,omitempty"`
+
+	// CacheProjects enables the listProjects cache without passing --cache
+	CacheProjects bool `toml:"cacheProjects,omitempty" yaml:"cacheProjects,omitempty"`
+
+	// UseKeychain stores api keys in the OS keychain instead of this file
+	UseKeychain bool `toml:"useKeychain,omitempty" yaml:"useKeychain,omitempty"`
+
+	// ProtectedOrgs are org ids that need the org name typed before anything in them is changed or deleted
+	ProtectedOrgs []string `toml:"protectedOrgs,omitempty" yaml:"protectedOrgs,omitempty"`
+
+	// Aliases are favourite build targets that can be given as --target @name
+	Aliases map[string]Alias `toml:"aliases,omitempty" yaml:"aliases,omitempty"`
+
+	// Region selects which region's api the org is served from when no --region is given
+	Region string `toml:"region,omitempty" yaml:"region,omitempty"`
+
+	// Regions maps region names to their api urls, adding to or overriding the built in ones
+	Regions map[string]string `toml:"regions,omitempty" yaml:"regions,omitempty"`
+}
+
+// Alias is a short name for a project and build target pair
+type Alias struct {
+	ProjectId     string `toml:"projectId" yaml:"projectId" json:"projectId"`
+	BuildTargetId string `toml:"buildTargetId" yaml:"buildTargetId" json:"buildTargetId"`
+}
+
+// Profile is a named set of credentials, empty values fall back on the top level settings
+type Profile struct {
+	ApiKey string `toml:"apiKey" yaml:"apiKey"`
+	OrgId  string `toml:"orgId" yaml:"orgId"`
+	Region string `toml:"region,omitempty" yaml:"region,omitempty"`
+}
+
+// Defaults are used for flags that are not given on the command line
+type Defaults struct {
+	Output        string `toml:"output,omitempty" yaml:"output,omitempty"`
+	ProjectId     string `toml:"projectId,omitempty" yaml:"projectId,omitempty"`
+	BuildTargetId string `toml:"buildTargetId,omitempty" yaml:"buildTargetId,omitempty"`
+}
+
+// Flags returns the defaults that are set keyed by flag name
+func (d Defaults) Flags() map[string]string {
+	values := map[string]string{
+		"output":        d.Output,
+		"projectId":     d.ProjectId,
+		"buildTargetId": d.BuildTargetId,
+	}
+
+	for name, value := range values {
+		if value == "" {
+			delete(values, name)
+		}
+	}
+	return values
+}
+
+// ConfigError is returned when the dot file can't be parsed or contains keys that aren't part of the schema
+type ConfigError struct {
+	Path string
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid config file %s: %v", e.Path, e.Err)
+}
+
+// IsProtectedOrg reports if orgId is listed in ProtectedOrgs
+func (s *CliSettings) IsProtectedOrg(orgId string) bool {
+	for _, protected := range s.ProtectedOrgs {
+		if protected == orgId {
+			return true
+		}
+	}
+	return false
+}
+
+// ProfileNames returns the sorted names of the profiles defined in the settings
+func (s *CliSettings) ProfileNames() []string {
+	names := make([]string, 0, len(s.Profiles))
+	for name := range s.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProfileNotFoundError is returned when a requested profile is not in the settings
+type ProfileNotFoundError struct {
+	Name      string
+	Available []string
+}
+
+func (e *ProfileNotFoundError) Error() string {
+	if len(e.Available) == 0 {
+		return fmt.Sprintf("profile %q not found, no profiles are defined", e.Name)
+	}
+	return fmt.Sprintf("profile %q not found, available profiles: %s", e.Name, strings.Join(e.Available, ", "))
+}
+
+func (s *CliSettings) profile(name string) (Profile, error) {
+	profile, ok := s.Profiles[name]
+	if !ok {
+		return Profile{}, &ProfileNotFoundError{name, s.ProfileNames()}
+	}
+	return profile, nil
 }
 
 func ParseDotFile() (*CliSettings, error) {
@@ -20,7 +136,7 @@ func ParseDotFile() (*CliSettings, error) {
 		return nil, err
 	}
 
-	f, err := os.Open(dotPath)
+	contents, err := ioutil.ReadFile(dotPath)
 	if os.IsNotExist(err) {
 		if err := CreateDotFile(dotPath); err != nil {
 			return nil, err
@@ -29,31 +145,248 @@ func ParseDotFile() (*CliSettings, error) {
 	} else if err != nil {
 		return nil, err
 	}
-	defer f.Close()
 
 	var data CliSettings
-	if _, err := toml.DecodeReader(f, &data); err != nil {
-		return nil, err
+	if isYAML(dotPath) {
+		if err := yaml.UnmarshalStrict(contents, &data); err != nil {
+			return nil, &ConfigError{dotPath, err}
+		}
+		return &data, nil
+	}
+
+	meta, err := toml.Decode(string(contents), &data)
+	if err != nil {
+		return nil, &ConfigError{dotPath, err}
+	}
+
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, 0, len(undecoded))
+		for _, key := range undecoded {
+			keys = append(keys, key.String())
+		}
+		return nil, &ConfigError{dotPath, fmt.Errorf("unknown keys: %s", strings.Join(keys, ", "))}
 	}
 
 	return &data, nil
 }
 
+const tomlTemplate = `# Unity Cloud Build cli settings, uncomment and fill in the values you need
+
+# credentials used when no --profile is given
+# apiKey = ""
+# orgId = ""
+
+# use the listProjects cache without passing --cache
+# cacheProjects = false
+
+# store api keys in the OS keychain instead of this file, 'ucb config --setup' saves them there
+# useKeychain = false
+
+# org ids whose name has to be typed before anything in them is changed or deleted, --force skips this
+# protectedOrgs = []
+
+# region the org's data is kept in, selects the api url unless --region or --api-url is given
+# region = "us"
+
+# values for flags that are not given on the command line
+# [defaults]
+# output = "table"
+# projectId = ""
+# buildTargetId = ""
+
+# api urls of regions selected with region or --region
+# [regions]
+# eu = ""
+
+# favourite build targets selected with --target @ios-prod, managed with 'ucb favorite'
+# [aliases.ios-prod]
+# projectId = ""
+# buildTargetId = ""
+
+# named credentials selected with --profile, empty values fall back on the ones above
+# [profiles.example]
+# apiKey = ""
+# orgId = ""
+# region = ""
+`
+
+const yamlTemplate = `# Unity Cloud Build cli settings, uncomment and fill in the values you need
+
+# credentials used when no --profile is given
+# apiKey: ""
+# orgId: ""
+
+# use the listProjects cache without passing --cache
+# cacheProjects: false
+
+# store api keys in the OS keychain instead of this file, 'ucb config --setup' saves them there
+# useKeychain: false
+
+# org ids whose name has to be typed before anything in them is changed or deleted, --force skips this
+# protectedOrgs: []
+
+# region the org's data is kept in, selects the api url unless --region or --api-url is given
+# region: us
+
+# values for flags that are not given on the command line
+# defaults:
+#   output: table
+#   projectId: ""
+#   buildTargetId: ""
+
+# api urls of regions selected with region or --region
+# regions:
+#   eu: ""
+
+# favourite build targets selected with --target @ios-prod, managed with 'ucb favorite'
+# aliases:
+#   ios-prod:
+#     projectId: ""
+#     buildTargetId: ""
+
+# named credentials selected with --profile, empty values fall back on the ones above
+# profiles:
+#   example:
+#     apiKey: ""
+#     orgId: ""
+#     region: ""
+`
+
+// CreateDotFile writes a template listing every valid setting
 func CreateDotFile(dotPath string) error {
-	f, err := os.Create(dotPath)
+	template := tomlTemplate
+	if isYAML(dotPath) {
+		template = yamlTemplate
+	}
+	return ioutil.WriteFile(dotPath, []byte(template), 0600)
+}
+
+func isYAML(dotPath string) bool {
+	ext := strings.ToLower(path.Ext(dotPath))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// GetCredentials returns the api key and org id stored in the dot file for profile, or the defaults if profile is empty.
+// With useKeychain set the api key is read from the OS keychain, falling back on the dot file when it isn't there
+func GetCredentials(profile string) (apiKey, orgId string, err error) {
+	data, err := ParseDotFile()
+	if err != nil {
+		return "", "", err
+	}
+
+	apiKey, orgId = data.ApiKey, data.OrgId
+	if data.UseKeychain {
+		if key, err := keychainGet(defaultAccount); err == nil && key != "" {
+			apiKey = key
+		}
+	}
+
+	if profile != "" {
+		p, err := data.profile(profile)
+		if err != nil {
+			return "", "", err
+		}
+
+		if data.UseKeychain {
+			if key, err := keychainGet(keychainAccount(profile)); err == nil && key != "" {
+				p.ApiKey = key
+			}
+		}
+
+		if p.ApiKey != "" {
+			apiKey = p.ApiKey
+		}
+		if p.OrgId != "" {
+			orgId = p.OrgId
+		}
+	}
+
+	return apiKey, orgId, nil
+}
+
+// GetRegion returns the region set for profile in the dot file, or the top level one if profile is empty or doesn't
+// set one
+func GetRegion(profile string) (string, error) {
+	data, err := ParseDotFile()
+	if err != nil {
+		return "", err
+	}
+
+	if profile != "" {
+		p, err := data.profile(profile)
+		if err != nil {
+			return "", err
+		}
+		if p.Region != "" {
+			return p.Region, nil
+		}
+	}
+	return data.Region, nil
+}
+
+// SetCredentials stores the api key and org id for profile in the dot file, or the defaults if profile is empty,
+// keeping any other settings. With useKeychain set the api key goes in the OS keychain instead, unless it is
+// unavailable
+func SetCredentials(profile, apiKey, orgId string) error {
+	dotPath, err := GetFilePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := ParseDotFile()
+	if err != nil {
+		return err
+	}
+
+	if data.UseKeychain {
+		if err := keychainSet(keychainAccount(profile), apiKey); err != nil {
+			fmt.Fprintf(os.Stderr, "could not use the keychain, storing the api key in %s: %v\n", dotPath, err)
+		} else {
+			apiKey = ""
+		}
+	}
+
+	if profile == "" {
+		data.ApiKey = apiKey
+		data.OrgId = orgId
+	} else {
+		if data.Profiles == nil {
+			data.Profiles = make(map[string]Profile)
+		}
+		data.Profiles[profile] = Profile{ApiKey: apiKey, OrgId: orgId, Region: data.Profiles[profile].Region}
+	}
+
+	return writeDotFile(dotPath, data)
+}
+
+func writeDotFile(dotPath string, data *CliSettings) error {
+	f, err := os.OpenFile(dotPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	data := CliSettings{}
+	if isYAML(dotPath) {
+		return yaml.NewEncoder(f).Encode(data)
+	}
+
 	if err := toml.NewEncoder(f).Encode(data); err != nil {
 		return err
 	}
 	return nil
 }
 
+// SetFilePath makes the settings read from and write to p instead of the dot file in the home directory
+func SetFilePath(p string) {
+	filePath = p
+}
+
+// GetFilePath returns the path set by SetFilePath, or ~/.cloudbuild
 func GetFilePath() (string, error) {
+	if filePath != "" {
+		return filePath, nil
+	}
+
 	usr, err := user.Current()
 	if err != nil {
 		return "", err
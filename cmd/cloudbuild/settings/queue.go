@@ -0,0 +1,78 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+)
+
+const queueFileName string = ".cloudbuild-queue"
+
+func getQueuePath() (string, error) {
+	dotPath, err := GetFilePath()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(path.Dir(dotPath), queueFileName), nil
+}
+
+// ReadQueue returns the entries of the offline queue oldest first, it is empty when nothing is queued
+func ReadQueue() ([]json.RawMessage, error) {
+	queuePath, err := getQueuePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(queuePath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// unlike the cache a corrupt queue is an error, dropping it would lose the changes in it
+	var entries []json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid offline queue %s: %v", queuePath, err)
+	}
+	return entries, nil
+}
+
+// AppendQueue adds v to the end of the offline queue
+func AppendQueue(v interface{}) error {
+	entries, err := ReadQueue()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return WriteQueue(append(entries, data))
+}
+
+// WriteQueue replaces the offline queue with entries, the file is removed when there are none. It is only readable
+// by the user as queued requests hold the changes they make
+func WriteQueue(entries []json.RawMessage) error {
+	queuePath, err := getQueuePath()
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		if err := os.Remove(queuePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	out, err := json.MarshalIndent(entries, "", "    ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(queuePath, out, 0600)
+}
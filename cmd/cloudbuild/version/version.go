@@ -0,0 +1,38 @@
+// Package version holds the build information of the cli, releases stamp it with
+//
+//	go build -ldflags "-X github.com/cmcpasserby/ucb/cmd/cloudbuild/version.Version=v1.0.0 \
+//	    -X github.com/cmcpasserby/ucb/cmd/cloudbuild/version.Commit=$(git rev-parse HEAD) \
+//	    -X github.com/cmcpasserby/ucb/cmd/cloudbuild/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/cloudbuild
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info is the build information printed by the version command
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get returns the stamped build information, falling back on the module version for go installed builds
+func Get() Info {
+	info := Info{Version, Commit, Date, runtime.Version()}
+
+	if info.Version == "dev" {
+		if build, ok := debug.ReadBuildInfo(); ok && build.Main.Version != "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version
+		}
+	}
+
+	return info
+}
@@ -1,6 +1,6 @@
 module github.com/cmcpasserby/ucb
 
-go 1.12
+go 1.16
 
 require (
 	github.com/BurntSushi/toml v0.3.1
@@ -12,6 +12,7 @@ require (
 	github.com/mattn/go-isatty v0.0.4 // indirect
 	github.com/mgutz/ansi v0.0.0-20170206155736-9520e82c474b // indirect
 	github.com/stretchr/testify v1.3.0 // indirect
-	golang.org/x/crypto v0.0.0-20190313024323-a1f597ede03a // indirect
+	golang.org/x/crypto v0.0.0-20190313024323-a1f597ede03a
 	gopkg.in/AlecAivazis/survey.v1 v1.6.2
+	gopkg.in/yaml.v2 v2.2.2
 )
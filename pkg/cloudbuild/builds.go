@@ -0,0 +1,489 @@
+package cloudbuild
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild/responses"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const shareUrl = "https://developer.cloud.unity3d.com/share/"
+
+// ErrBuildFinished is returned when cancelling a build that has already completed
+var ErrBuildFinished = errors.New("build already complete")
+
+type BuildsService struct {
+	*client
+}
+
+func NewBuildsService(apiKey, orgId string, opts ...Option) *BuildsService {
+	return &BuildsService{
+		client: newClient(apiKey, orgId, opts...),
+	}
+}
+
+func (c *BuildsService) Start(projectId, targetId string, clean bool) (*responses.Build, error) {
+	return c.StartContext(context.Background(), projectId, targetId, clean)
+}
+
+func (c *BuildsService) StartContext(ctx context.Context, projectId, targetId string, clean bool) (*responses.Build, error) {
+	return c.StartWithContext(ctx, projectId, targetId, StartOptions{Clean: clean})
+}
+
+// IdempotencyHeader is the header a start request's idempotency key is sent in
+const IdempotencyHeader = "Idempotency-Key"
+
+// startSkew allows for the api's clock being behind ours when matching builds to an earlier start attempt
+const startSkew = time.Minute
+
+// StartOptions configures how StartWith queues a build
+type StartOptions struct {
+	Clean bool // force a clean build
+
+	// IdempotencyKey is sent in the Idempotency-Key header. With a key, a start that fails without a definite answer,
+	// eg it timed out after the api accepted it, is only retried if the target has no build created since the first
+	// attempt, otherwise that build is returned. Note any build created in that time matches, including one queued by
+	// someone else
+	IdempotencyKey string
+
+	// Since is when an earlier attempt with the same IdempotencyKey was sent, eg by a previous run, a build created
+	// after it is returned instead of queuing another
+	Since time.Time
+}
+
+func (c *BuildsService) StartWith(projectId, targetId string, opts StartOptions) (*responses.Build, error) {
+	return c.StartWithContext(context.Background(), projectId, targetId, opts)
+}
+
+func (c *BuildsService) StartWithContext(ctx context.Context, projectId, targetId string, opts StartOptions) (*responses.Build, error) {
+	if projectId == "" {
+		return nil, errNoProjectId
+	}
+
+	if opts.IdempotencyKey == "" {
+		return c.start(ctx, projectId, targetId, opts)
+	}
+
+	sent := opts.Since
+	for attempt := 0; ; attempt++ {
+		if !sent.IsZero() {
+			if build, err := c.startedSince(ctx, projectId, targetId, sent); err != nil || build != nil {
+				return build, err
+			}
+		} else {
+			sent = time.Now()
+		}
+
+		build, err := c.start(ctx, projectId, targetId, opts)
+		if err == nil || attempt >= c.maxRetries || ctx.Err() != nil || !retryableStart(err) {
+			return build, err
+		}
+
+		delay := retryBaseDelay << uint(attempt)
+		if rateErr, ok := err.(*RateLimitError); ok && rateErr.RetryAfter > 0 {
+			delay = rateErr.RetryAfter
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *BuildsService) start(ctx context.Context, projectId, targetId string, opts StartOptions) (*responses.Build, error) {
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets/%s/builds", c.OrgId, projectId, targetId)
+
+	body := struct {
+		Clean bool `json:"clean"`
+	}{opts.Clean}
+
+	req, err := c.newRequest(ctx, "POST", path, body)
+	if err != nil {
+		return nil, err
+	}
+	if opts.IdempotencyKey != "" {
+		req.Header.Set(IdempotencyHeader, opts.IdempotencyKey)
+	}
+
+	var builds []responses.Build
+	if _, err := c.do(req, &builds); err != nil {
+		return nil, err
+	}
+
+	if len(builds) == 0 {
+		return nil, errors.New("no build was queued")
+	}
+
+	if builds[0].Error != "" {
+		return nil, errors.New(builds[0].Error)
+	}
+
+	return &builds[0], nil
+}
+
+// startedSince returns the newest build of a target created since t, or nil if there isn't one
+func (c *BuildsService) startedSince(ctx context.Context, projectId, targetId string, t time.Time) (*responses.Build, error) {
+	builds, err := c.ListContext(ctx, projectId, targetId, ListBuildsOptions{Limit: 10})
+	if err != nil {
+		return nil, err
+	}
+
+	var newest *responses.Build
+	for i := range builds {
+		if builds[i].Created.Before(t.Add(-startSkew)) {
+			continue
+		}
+		if newest == nil || builds[i].Number > newest.Number {
+			newest = &builds[i]
+		}
+	}
+	return newest, nil
+}
+
+// retryableStart reports if a failed start may not have reached the api or may succeed if sent again
+func retryableStart(err error) bool {
+	switch e := err.(type) {
+	case *RateLimitError:
+		return true
+	case *ResponseError:
+		return e.StatusCode >= 500
+	case *url.Error:
+		// the request failed or timed out without a response, it may or may not have reached the api
+		return true
+	}
+
+	// the api answered but not with a build, eg {"error": "..."}, sending it again won't help
+	return false
+}
+
+// ListBuildsOptions filters the builds returned by List
+type ListBuildsOptions struct {
+	Status responses.BuildStatus // only return builds with this status, empty returns all
+	Limit  int                   // maximum number of builds to return, 0 returns all
+	Since  time.Time             // only return builds that finished after this time, zero returns all
+}
+
+func (c *BuildsService) List(projectId, targetId string, opts ListBuildsOptions) ([]responses.Build, error) {
+	return c.ListContext(context.Background(), projectId, targetId, opts)
+}
+
+func (c *BuildsService) ListContext(ctx context.Context, projectId, targetId string, opts ListBuildsOptions) ([]responses.Build, error) {
+	if projectId == "" {
+		return nil, errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets/%s/builds", c.OrgId, projectId, targetId)
+
+	query := url.Values{}
+	if opts.Status != "" {
+		query.Set("buildStatus", string(opts.Status))
+	}
+
+	// the api can't filter by finish time so every build is listed and the limit applied after filtering
+	limit := opts.Limit
+	if !opts.Since.IsZero() {
+		limit = 0
+	}
+
+	var builds []responses.Build
+	if err := c.getN(ctx, path, query, limit, &builds); err != nil {
+		return nil, err
+	}
+
+	if opts.Since.IsZero() {
+		return builds, nil
+	}
+
+	filtered := builds[:0]
+	for _, build := range builds {
+		if build.Finished.After(opts.Since) {
+			filtered = append(filtered, build)
+		}
+	}
+	if opts.Limit > 0 && len(filtered) > opts.Limit {
+		filtered = filtered[:opts.Limit]
+	}
+	return filtered, nil
+}
+
+func (c *BuildsService) Get(projectId, targetId string, buildNumber int) (*responses.Build, error) {
+	return c.GetContext(context.Background(), projectId, targetId, buildNumber)
+}
+
+func (c *BuildsService) GetContext(ctx context.Context, projectId, targetId string, buildNumber int) (*responses.Build, error) {
+	if projectId == "" {
+		return nil, errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets/%s/builds/%d", c.OrgId, projectId, targetId, buildNumber)
+
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var build responses.Build
+	if _, err := c.do(req, &build); err != nil {
+		return nil, err
+	}
+
+	return &build, nil
+}
+
+// Wait polls a build every interval until it has finished, onChange is called with the build each time its status
+// changes and may be nil
+func (c *BuildsService) Wait(projectId, targetId string, buildNumber int, interval time.Duration, onChange func(*responses.Build)) (*responses.Build, error) {
+	return c.WaitContext(context.Background(), projectId, targetId, buildNumber, interval, onChange)
+}
+
+func (c *BuildsService) WaitContext(ctx context.Context, projectId, targetId string, buildNumber int, interval time.Duration, onChange func(*responses.Build)) (*responses.Build, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lastStatus responses.BuildStatus
+	for {
+		build, err := c.GetContext(ctx, projectId, targetId, buildNumber)
+		if err != nil {
+			return nil, err
+		}
+
+		if build.Status != lastStatus {
+			lastStatus = build.Status
+			if onChange != nil {
+				onChange(build)
+			}
+		}
+
+		if build.Status.Finished() {
+			return build, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *BuildsService) GetArtifactURL(projectId, targetId string, buildNumber int) (string, error) {
+	return c.GetArtifactURLContext(context.Background(), projectId, targetId, buildNumber)
+}
+
+func (c *BuildsService) GetArtifactURLContext(ctx context.Context, projectId, targetId string, buildNumber int) (string, error) {
+	build, err := c.GetContext(ctx, projectId, targetId, buildNumber)
+	if err != nil {
+		return "", err
+	}
+
+	if link := build.Links.DownloadPrimary; link != nil && link.Href != "" {
+		return link.Href, nil
+	}
+
+	for _, artifact := range build.Links.Artifacts {
+		if artifact.Primary && len(artifact.Files) > 0 && artifact.Files[0].Href != "" {
+			return artifact.Files[0].Href, nil
+		}
+	}
+
+	return "", fmt.Errorf("build %d has no artifact to download (status: %s)", buildNumber, build.Status)
+}
+
+// GetLog returns the text log of a build, for a build still in progress this is the log so far,
+// the caller must close the returned reader
+func (c *BuildsService) GetLog(projectId, targetId string, buildNumber int) (io.ReadCloser, error) {
+	return c.GetLogContext(context.Background(), projectId, targetId, buildNumber)
+}
+
+func (c *BuildsService) GetLogContext(ctx context.Context, projectId, targetId string, buildNumber int) (io.ReadCloser, error) {
+	if projectId == "" {
+		return nil, errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets/%s/builds/%d/log", c.OrgId, projectId, targetId, buildNumber)
+
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain")
+
+	resp, err := c.doStream(req)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// DownloadArtifact streams the artifact at artifactUrl to w, returning the number of bytes written
+func (c *BuildsService) DownloadArtifact(artifactUrl string, w io.Writer) (int64, error) {
+	return c.DownloadArtifactContext(context.Background(), artifactUrl, w)
+}
+
+func (c *BuildsService) DownloadArtifactContext(ctx context.Context, artifactUrl string, w io.Writer) (int64, error) {
+	return c.DownloadArtifactProgressContext(ctx, artifactUrl, w, nil)
+}
+
+// ProgressFunc is called as a download is written with the bytes so far and the total, total is -1 when the server
+// doesn't send a Content-Length
+type ProgressFunc func(written, total int64)
+
+// DownloadArtifactProgress is DownloadArtifact calling progress as the artifact is written
+func (c *BuildsService) DownloadArtifactProgress(artifactUrl string, w io.Writer, progress ProgressFunc) (int64, error) {
+	return c.DownloadArtifactProgressContext(context.Background(), artifactUrl, w, progress)
+}
+
+func (c *BuildsService) DownloadArtifactProgressContext(ctx context.Context, artifactUrl string, w io.Writer, progress ProgressFunc) (int64, error) {
+	// artifact links are pre-signed so no auth header is added
+	req, err := http.NewRequest("GET", artifactUrl, nil)
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	// artifacts are already compressed and the Content-Length is needed for progress
+	req.Header.Set("Accept-Encoding", "identity")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("artifact download failed: %s", resp.Status)
+	}
+
+	if progress != nil {
+		w = &progressWriter{w: w, total: resp.ContentLength, progress: progress}
+	}
+
+	return io.Copy(w, resp.Body)
+}
+
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.progress(p.written, p.total)
+	return n, err
+}
+
+func (c *BuildsService) Cancel(projectId, targetId string, buildNumber int) error {
+	return c.CancelContext(context.Background(), projectId, targetId, buildNumber)
+}
+
+func (c *BuildsService) CancelContext(ctx context.Context, projectId, targetId string, buildNumber int) error {
+	if projectId == "" {
+		return errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets/%s/builds/%d", c.OrgId, projectId, targetId, buildNumber)
+
+	req, err := c.newRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.do(req, nil); err != nil {
+		if isBuildFinished(err) {
+			return ErrBuildFinished
+		}
+		return err
+	}
+
+	return nil
+}
+
+// isBuildFinished reports whether err is the api rejecting cancelling a build that is no longer queued or running,
+// a 409 or 410, or a 400 or 422 whose body gives a finished build status. Other rejections are left as they are
+func isBuildFinished(err error) bool {
+	respErr, ok := err.(*ResponseError)
+	if !ok {
+		return false
+	}
+
+	switch respErr.StatusCode {
+	case http.StatusConflict, http.StatusGone:
+		return true
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+	default:
+		return false
+	}
+
+	var payload struct {
+		BuildStatus responses.BuildStatus `json:"buildStatus"`
+	}
+	if err := json.Unmarshal([]byte(respErr.Body), &payload); err == nil && payload.BuildStatus.Finished() {
+		return true
+	}
+
+	msg := strings.ToLower(respErr.Message)
+	return strings.Contains(msg, "already") && (strings.Contains(msg, "finished") || strings.Contains(msg, "complete"))
+}
+
+// CreateShareLink creates a public link to a build's artifact and returns its url
+func (c *BuildsService) CreateShareLink(projectId, targetId string, buildNumber int) (string, error) {
+	return c.CreateShareLinkContext(context.Background(), projectId, targetId, buildNumber)
+}
+
+func (c *BuildsService) CreateShareLinkContext(ctx context.Context, projectId, targetId string, buildNumber int) (string, error) {
+	if projectId == "" {
+		return "", errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets/%s/builds/%d/share", c.OrgId, projectId, targetId, buildNumber)
+
+	req, err := c.newRequest(ctx, "POST", path, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var link responses.ShareLink
+	if _, err := c.do(req, &link); err != nil {
+		return "", err
+	}
+
+	if link.ShareId == "" {
+		return "", errors.New("api did not return a share id")
+	}
+
+	return shareUrl + link.ShareId, nil
+}
+
+// RevokeShareLink invalidates a build's share link
+func (c *BuildsService) RevokeShareLink(projectId, targetId string, buildNumber int) error {
+	return c.RevokeShareLinkContext(context.Background(), projectId, targetId, buildNumber)
+}
+
+func (c *BuildsService) RevokeShareLinkContext(ctx context.Context, projectId, targetId string, buildNumber int) error {
+	if projectId == "" {
+		return errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets/%s/builds/%d/share", c.OrgId, projectId, targetId, buildNumber)
+
+	req, err := c.newRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.do(req, nil)
+	return err
+}
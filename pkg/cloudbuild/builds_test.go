@@ -0,0 +1,39 @@
+package cloudbuild
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCancelFinished(t *testing.T) {
+	bodies := map[int]string{
+		http.StatusConflict:            `{"error":"build is not running"}`,
+		http.StatusGone:                ``,
+		http.StatusBadRequest:          `{"error":"cannot cancel","buildStatus":"success"}`,
+		http.StatusUnprocessableEntity: `{"error":"build has already finished"}`,
+	}
+
+	for status, body := range bodies {
+		api := newFakeApi(t)
+		api.handle("DELETE /api/v1/orgs/example/projects/my-game/buildtargets/ios/builds/42", serveError(status, body))
+
+		if err := NewBuildsService(testApiKey, testOrgId, api.options()...).Cancel("my-game", "ios", 42); err != ErrBuildFinished {
+			t.Errorf("%d %s: got %v, want ErrBuildFinished", status, body, err)
+		}
+	}
+}
+
+func TestCancelRejected(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("DELETE /api/v1/orgs/example/projects/my-game/buildtargets/ios/builds/42", serveError(http.StatusBadRequest, `{"error":"invalid build number"}`))
+
+	err := NewBuildsService(testApiKey, testOrgId, api.options()...).Cancel("my-game", "ios", 42)
+
+	respErr, ok := err.(*ResponseError)
+	if !ok {
+		t.Fatalf("got %T %v, want the api's *ResponseError", err, err)
+	}
+	if respErr.Message != "invalid build number" {
+		t.Errorf("Message = %q, want \"invalid build number\"", respErr.Message)
+	}
+}
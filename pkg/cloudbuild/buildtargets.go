@@ -0,0 +1,366 @@
+package cloudbuild
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild/responses"
+	"net/http"
+	"strings"
+)
+
+var errNoProjectId = errors.New("project id is required")
+
+// ErrCacheDisabled is returned when clearing the build cache of a target that doesn't cache builds
+var ErrCacheDisabled = errors.New("build caching is disabled for the build target")
+
+// BuildTargetConfig describes a build target to create or update, empty fields are left out of the request
+type BuildTargetConfig struct {
+	Name         string             `json:"name,omitempty"`
+	Platform     responses.Platform `json:"platform,omitempty"`
+	Branch       string             `json:"branch,omitempty"`
+	UnityVersion string             `json:"unityVersion,omitempty"`
+	Enabled      *bool              `json:"enabled,omitempty"`
+}
+
+// body converts the config into the nested layout the api expects
+func (t BuildTargetConfig) body() interface{} {
+	type scm struct {
+		Branch string `json:"branch,omitempty"`
+	}
+
+	type settings struct {
+		UnityVersion string `json:"unityVersion,omitempty"`
+		Scm          *scm   `json:"scm,omitempty"`
+	}
+
+	body := struct {
+		Name     string             `json:"name,omitempty"`
+		Platform responses.Platform `json:"platform,omitempty"`
+		Enabled  *bool              `json:"enabled,omitempty"`
+		Settings *settings          `json:"settings,omitempty"`
+	}{
+		Name:     t.Name,
+		Platform: t.Platform,
+		Enabled:  t.Enabled,
+	}
+
+	if t.UnityVersion != "" || t.Branch != "" {
+		body.Settings = &settings{UnityVersion: t.UnityVersion}
+		if t.Branch != "" {
+			body.Settings.Scm = &scm{Branch: t.Branch}
+		}
+	}
+
+	return body
+}
+
+type BuildTargetsService struct {
+	*client
+}
+
+func NewBuildTargetsService(apiKey, orgId string, opts ...Option) *BuildTargetsService {
+	return &BuildTargetsService{
+		client: newClient(apiKey, orgId, opts...),
+	}
+}
+
+func (c *BuildTargetsService) ListAll(projectId string) ([]responses.BuildTarget, error) {
+	return c.ListAllContext(context.Background(), projectId)
+}
+
+func (c *BuildTargetsService) ListAllContext(ctx context.Context, projectId string) ([]responses.BuildTarget, error) {
+	if projectId == "" {
+		return nil, errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets", c.OrgId, projectId)
+
+	var targets []responses.BuildTarget
+	if err := c.getAll(ctx, path, nil, &targets); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+func (c *BuildTargetsService) Get(projectId, targetId string) (*responses.BuildTarget, error) {
+	return c.GetContext(context.Background(), projectId, targetId)
+}
+
+func (c *BuildTargetsService) GetContext(ctx context.Context, projectId, targetId string) (*responses.BuildTarget, error) {
+	if projectId == "" {
+		return nil, errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets/%s", c.OrgId, projectId, targetId)
+
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var target responses.BuildTarget
+	if _, err := c.do(req, &target); err != nil {
+		return nil, err
+	}
+
+	return &target, nil
+}
+
+func (c *BuildTargetsService) Create(projectId string, target BuildTargetConfig) (*responses.BuildTarget, error) {
+	return c.CreateContext(context.Background(), projectId, target)
+}
+
+func (c *BuildTargetsService) CreateContext(ctx context.Context, projectId string, target BuildTargetConfig) (*responses.BuildTarget, error) {
+	if projectId == "" {
+		return nil, errNoProjectId
+	}
+
+	if target.Name == "" || target.Platform == "" {
+		return nil, errors.New("build target name and platform are required")
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets", c.OrgId, projectId)
+
+	req, err := c.newRequest(ctx, "POST", path, target.body())
+	if err != nil {
+		return nil, err
+	}
+
+	var created responses.BuildTarget
+	if _, err := c.do(req, &created); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// Update applies patch to a build target, only the fields set in patch are changed
+func (c *BuildTargetsService) Update(projectId, targetId string, patch BuildTargetConfig) (*responses.BuildTarget, error) {
+	return c.UpdateContext(context.Background(), projectId, targetId, patch)
+}
+
+func (c *BuildTargetsService) UpdateContext(ctx context.Context, projectId, targetId string, patch BuildTargetConfig) (*responses.BuildTarget, error) {
+	if projectId == "" {
+		return nil, errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets/%s", c.OrgId, projectId, targetId)
+
+	req, err := c.newRequest(ctx, "PUT", path, patch.body())
+	if err != nil {
+		return nil, err
+	}
+
+	var updated responses.BuildTarget
+	if _, err := c.do(req, &updated); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// SetBranch changes the branch a build target builds from
+func (c *BuildTargetsService) SetBranch(projectId, targetId, branch string) (*responses.BuildTarget, error) {
+	return c.SetBranchContext(context.Background(), projectId, targetId, branch)
+}
+
+func (c *BuildTargetsService) SetBranchContext(ctx context.Context, projectId, targetId, branch string) (*responses.BuildTarget, error) {
+	if branch == "" {
+		return nil, errors.New("branch is required")
+	}
+	return c.UpdateContext(ctx, projectId, targetId, BuildTargetConfig{Branch: branch})
+}
+
+// SetCredential makes a build target sign its builds with the credential credId
+func (c *BuildTargetsService) SetCredential(projectId, targetId, credId string) (*responses.BuildTarget, error) {
+	return c.SetCredentialContext(context.Background(), projectId, targetId, credId)
+}
+
+func (c *BuildTargetsService) SetCredentialContext(ctx context.Context, projectId, targetId, credId string) (*responses.BuildTarget, error) {
+	if projectId == "" {
+		return nil, errNoProjectId
+	}
+
+	type signing struct {
+		CredentialId string `json:"credentialid"`
+	}
+
+	body := struct {
+		Credentials struct {
+			Signing signing `json:"signing"`
+		} `json:"credentials"`
+	}{}
+	body.Credentials.Signing.CredentialId = credId
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets/%s", c.OrgId, projectId, targetId)
+
+	req, err := c.newRequest(ctx, "PUT", path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated responses.BuildTarget
+	if _, err := c.do(req, &updated); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// SetIOSCredential is SetCredential for an iOS credential, nothing is changed if the build target isn't an iOS one
+func (c *BuildTargetsService) SetIOSCredential(projectId, targetId, credId string) (*responses.BuildTarget, error) {
+	return c.SetIOSCredentialContext(context.Background(), projectId, targetId, credId)
+}
+
+func (c *BuildTargetsService) SetIOSCredentialContext(ctx context.Context, projectId, targetId, credId string) (*responses.BuildTarget, error) {
+	target, err := c.GetContext(ctx, projectId, targetId)
+	if err != nil {
+		return nil, err
+	}
+
+	if target.Platform != responses.PlatformIOS {
+		return nil, fmt.Errorf("build target %s has platform %q, only ios build targets can use an ios credential", targetId, target.Platform)
+	}
+
+	return c.SetCredentialContext(ctx, projectId, targetId, credId)
+}
+
+func (c *BuildTargetsService) Delete(projectId, targetId string) (*http.Response, error) {
+	return c.DeleteContext(context.Background(), projectId, targetId)
+}
+
+func (c *BuildTargetsService) DeleteContext(ctx context.Context, projectId, targetId string) (*http.Response, error) {
+	if projectId == "" {
+		return nil, errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets/%s", c.OrgId, projectId, targetId)
+
+	req, err := c.newRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (c *BuildTargetsService) GetEnvVars(projectId, targetId string) (map[string]string, error) {
+	return c.GetEnvVarsContext(context.Background(), projectId, targetId)
+}
+
+func (c *BuildTargetsService) GetEnvVarsContext(ctx context.Context, projectId, targetId string) (map[string]string, error) {
+	if projectId == "" {
+		return nil, errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets/%s/envvars", c.OrgId, projectId, targetId)
+
+	req, err := c.newRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]string)
+	if _, err := c.do(req, &vars); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
+
+// SetEnvVars replaces all of a build target's environment variables with vars
+func (c *BuildTargetsService) SetEnvVars(projectId, targetId string, vars map[string]string) (map[string]string, error) {
+	return c.SetEnvVarsContext(context.Background(), projectId, targetId, vars)
+}
+
+func (c *BuildTargetsService) SetEnvVarsContext(ctx context.Context, projectId, targetId string, vars map[string]string) (map[string]string, error) {
+	if projectId == "" {
+		return nil, errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets/%s/envvars", c.OrgId, projectId, targetId)
+
+	req, err := c.newRequest(ctx, "PUT", path, vars)
+	if err != nil {
+		return nil, err
+	}
+	req = withoutQueue(req)
+
+	updated := make(map[string]string)
+	if _, err := c.do(req, &updated); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+func (c *BuildTargetsService) DeleteEnvVar(projectId, targetId, key string) error {
+	return c.DeleteEnvVarContext(context.Background(), projectId, targetId, key)
+}
+
+func (c *BuildTargetsService) DeleteEnvVarContext(ctx context.Context, projectId, targetId, key string) error {
+	vars, err := c.GetEnvVarsContext(ctx, projectId, targetId)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := vars[key]; !ok {
+		return fmt.Errorf("environment variable %q is not set", key)
+	}
+	delete(vars, key)
+
+	_, err = c.SetEnvVarsContext(ctx, projectId, targetId, vars)
+	return err
+}
+
+// ClearCache deletes the cached library and build data of a build target so its next build starts clean
+func (c *BuildTargetsService) ClearCache(projectId, targetId string) error {
+	return c.ClearCacheContext(context.Background(), projectId, targetId)
+}
+
+func (c *BuildTargetsService) ClearCacheContext(ctx context.Context, projectId, targetId string) error {
+	if projectId == "" {
+		return errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/buildtargets/%s/cache", c.OrgId, projectId, targetId)
+
+	req, err := c.newRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := c.do(req, nil); err != nil {
+		if isCacheDisabled(err) {
+			return ErrCacheDisabled
+		}
+		return err
+	}
+
+	return nil
+}
+
+// isCacheDisabled reports whether err is the api rejecting clearing a cache that caching is turned off for, other
+// rejections such as invalid ids or missing permissions are left as they are
+func isCacheDisabled(err error) bool {
+	respErr, ok := err.(*ResponseError)
+	if !ok {
+		return false
+	}
+
+	switch respErr.StatusCode {
+	case http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity:
+	default:
+		return false
+	}
+
+	msg := strings.ToLower(respErr.Message + " " + respErr.Body)
+	return strings.Contains(msg, "cach") && (strings.Contains(msg, "disabled") || strings.Contains(msg, "not enabled"))
+}
@@ -0,0 +1,40 @@
+package cloudbuild
+
+import (
+	"net/http"
+	"testing"
+)
+
+// serveError answers with status and body
+func serveError(status int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}
+}
+
+func TestClearCacheDisabled(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("DELETE /api/v1/orgs/example/projects/my-game/buildtargets/ios/cache", serveError(http.StatusUnprocessableEntity, `{"error":"Caching is disabled for this build target"}`))
+
+	err := NewBuildTargetsService(testApiKey, testOrgId, api.options()...).ClearCache("my-game", "ios")
+	if err != ErrCacheDisabled {
+		t.Errorf("got %v, want ErrCacheDisabled", err)
+	}
+}
+
+func TestClearCacheRejected(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("DELETE /api/v1/orgs/example/projects/my-game/buildtargets/ios/cache", serveError(http.StatusBadRequest, `{"error":"invalid build target id"}`))
+
+	err := NewBuildTargetsService(testApiKey, testOrgId, api.options()...).ClearCache("my-game", "ios")
+
+	respErr, ok := err.(*ResponseError)
+	if !ok {
+		t.Fatalf("got %T %v, want the api's *ResponseError", err, err)
+	}
+	if respErr.Message != "invalid build target id" {
+		t.Errorf("Message = %q, want \"invalid build target id\"", respErr.Message)
+	}
+}
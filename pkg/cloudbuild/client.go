@@ -2,15 +2,18 @@ package cloudbuild
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"log"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"time"
 )
 
 const baseUrl = "build-api.cloud.unity3d.com"
@@ -20,21 +23,153 @@ type client struct {
 	ApiKey     string
 	OrgId      string
 	httpClient *http.Client
+	maxRetries int
+	timeout    time.Duration
+	proxy      *url.URL
+	logger     Logger
+	dryRun     io.Writer
+	raw        io.Writer
+	pageSize   int
+	queue      func(QueuedRequest) error
 }
 
-func newClient(apiKey, orgId string) *client {
-	return &client{
+// Option configures the client used by a service
+type Option func(*client)
+
+// WithMaxRetries sets how many times an idempotent request failing with a connection error, 429 or 5xx is retried, 0
+// disables retries
+func WithMaxRetries(n int) Option {
+	return func(c *client) {
+		c.maxRetries = n
+	}
+}
+
+// WithTimeout sets how long each request attempt may wait for a response, 0 disables the timeout
+func WithTimeout(d time.Duration) Option {
+	return func(c *client) {
+		c.timeout = d
+	}
+}
+
+// WithProxy sends requests through proxy instead of the one from HTTPS_PROXY and NO_PROXY, it has no effect when
+// WithHTTPClient is given a client with its own Transport
+func WithProxy(proxy *url.URL) Option {
+	return func(c *client) {
+		c.proxy = proxy
+	}
+}
+
+// WithBaseURL sends requests to baseUrl instead of the Unity Cloud Build api, eg an httptest.Server's url. Together
+// with WithHTTPClient this lets the services run against a fake api
+func WithBaseURL(baseUrl *url.URL) Option {
+	return func(c *client) {
+		c.BaseUrl = baseUrl
+	}
+}
+
+// WithHTTPClient sets the http client used to make requests, its transport is wrapped with the retry handling
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithLogger logs every request and response to logger as human readable lines
+func WithLogger(logger *log.Logger) Option {
+	return WithRequestLogger(textLogger{logger})
+}
+
+// WithRequestLogger sends an entry for every request and response to logger
+func WithRequestLogger(logger Logger) Option {
+	return func(c *client) {
+		c.logger = logger
+	}
+}
+
+// WithDryRun prints requests that would change data to w instead of sending them, those requests fail with ErrDryRun
+func WithDryRun(w io.Writer) Option {
+	return func(c *client) {
+		c.dryRun = w
+	}
+}
+
+// WithRawResponses writes the body of every GET response to w, indented when it is json, before it is decoded
+func WithRawResponses(w io.Writer) Option {
+	return func(c *client) {
+		c.raw = w
+	}
+}
+
+// WithPageSize sets how many results each request for a page of a list asks for, it is clamped to between 1 and
+// MaxPageSize
+func WithPageSize(n int) Option {
+	return func(c *client) {
+		switch {
+		case n < 1:
+			c.pageSize = 1
+		case n > MaxPageSize:
+			c.pageSize = MaxPageSize
+		default:
+			c.pageSize = n
+		}
+	}
+}
+
+func newClient(apiKey, orgId string, opts ...Option) *client {
+	c := &client{
 		BaseUrl:    &url.URL{Scheme: "https", Host: baseUrl},
 		ApiKey:     apiKey,
 		OrgId:      orgId,
-		httpClient: http.DefaultClient,
+		maxRetries: defaultMaxRetries,
+		timeout:    DefaultTimeout,
+		pageSize:   DefaultPageSize,
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	var httpClient http.Client
+	if c.httpClient != nil {
+		httpClient = *c.httpClient
+	}
+	if httpClient.Transport == nil && c.proxy != nil {
+		httpClient.Transport = newProxyTransport(c.proxy)
+	}
+	httpClient.Transport = newCompressionTransport(newLoggingTransport(httpClient.Transport, c.logger))
+	httpClient.Transport = newTimeoutTransport(httpClient.Transport, c.timeout)
+	httpClient.Transport = newRetryTransport(httpClient.Transport, c.maxRetries)
+	httpClient.Transport = newQueueTransport(httpClient.Transport, c.queue)
+	httpClient.Transport = newDryRunTransport(httpClient.Transport, c.dryRun)
+	c.httpClient = &httpClient
+
+	return c
 }
 
-func (c *client) newRequest(method, path string, body interface{}) (*http.Request, error) {
+// newProxyTransport matches http.DefaultTransport, which already honours HTTPS_PROXY and NO_PROXY, other than
+// always using proxy
+func newProxyTransport(proxy *url.URL) *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyURL(proxy),
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+func (c *client) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
 	rel := &url.URL{Path: path}
 	u := c.BaseUrl.ResolveReference(rel)
 
+	return c.newRequestURL(ctx, method, u.String(), body)
+}
+
+func (c *client) newRequestURL(ctx context.Context, method, u string, body interface{}) (*http.Request, error) {
 	var buf io.ReadWriter
 	if body != nil {
 		buf = new(bytes.Buffer)
@@ -44,10 +179,11 @@ func (c *client) newRequest(method, path string, body interface{}) (*http.Reques
 		}
 	}
 
-	req, err := http.NewRequest(method, u.String(), buf)
+	req, err := http.NewRequest(method, u, buf)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
@@ -56,7 +192,7 @@ func (c *client) newRequest(method, path string, body interface{}) (*http.Reques
 	return req, nil
 }
 
-func (c *client) newFormRequest(method, path string, form map[string]io.Reader) (*http.Request, error) {
+func (c *client) newFormRequest(ctx context.Context, method, path string, form map[string]io.Reader) (*http.Request, error) {
 	rel := &url.URL{Path: path}
 	u := c.BaseUrl.ResolveReference(rel)
 
@@ -92,36 +228,78 @@ func (c *client) newFormRequest(method, path string, form map[string]io.Reader)
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 
 	req.Header.Set("Content-Type", w.FormDataContentType())
 	req.Header.Set("Authorization", fmt.Sprintf("Basic %s", c.ApiKey))
 
-	return req, nil
+	// the forms are credential uploads with their signing files and passwords
+	return withoutQueue(req), nil
 }
 
 func (c *client) do(req *http.Request, v interface{}) (*http.Response, error) {
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doStream(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 300 {
-		bodyString, err := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode == 204 { // no content to decode
+		return resp, nil
+	}
+
+	var body io.Reader = resp.Body
+	if c.raw != nil && req.Method == "GET" {
+		data, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
 			return nil, err
 		}
-		return nil, errors.New(string(bodyString))
+		if err := writeRaw(c.raw, data); err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
 	}
 
-	if resp.StatusCode == 204 { // no content to decode
-		return resp, nil
+	if v != nil {
+		if err := json.NewDecoder(body).Decode(v); err != nil {
+			return nil, err
+		}
 	}
+	return resp, nil
+}
 
-	if v != nil {
-		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+func writeRaw(w io.Writer, data []byte) error {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "    "); err == nil {
+		data = indented.Bytes()
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// doStream is do without decoding, the caller must close the response body
+func (c *client) doStream(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if urlErr, ok := err.(*url.Error); ok && (urlErr.Err == ErrDryRun || urlErr.Err == ErrQueued) {
+		return nil, urlErr.Err
+	} else if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+
+		bodyString, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
 			return nil, err
 		}
+
+		return nil, newResponseError(resp, bodyString)
 	}
+
 	return resp, nil
 }
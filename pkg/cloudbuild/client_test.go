@@ -0,0 +1,113 @@
+package cloudbuild
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	testApiKey = "0123456789abcdef0123456789abcdef"
+	testOrgId  = "example"
+)
+
+// fakeApi is an httptest.Server standing in for the api, requests are answered by the handler registered for their
+// method and path and anything else is a 404
+type fakeApi struct {
+	*httptest.Server
+	routes   map[string]http.HandlerFunc
+	requests []*http.Request
+}
+
+func newFakeApi(t *testing.T) *fakeApi {
+	t.Helper()
+
+	api := &fakeApi{routes: make(map[string]http.HandlerFunc)}
+	api.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		api.requests = append(api.requests, r)
+
+		handler, ok := api.routes[r.Method+" "+r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(api.Close)
+
+	return api
+}
+
+// handle registers handler for requests like "GET /api/v1/orgs/example/projects"
+func (api *fakeApi) handle(route string, handler http.HandlerFunc) {
+	api.routes[route] = handler
+}
+
+// options points a service at the fake api, retries are disabled so error paths answer straight away
+func (api *fakeApi) options(opts ...Option) []Option {
+	u, _ := url.Parse(api.URL + "/")
+	return append([]Option{WithBaseURL(u), WithHTTPClient(api.Client()), WithMaxRetries(0)}, opts...)
+}
+
+// fixture reads a file from testdata
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// serveFixture answers with status and the fixture as a json body
+func serveFixture(t *testing.T, status int, name string) http.HandlerFunc {
+	data := fixture(t, name)
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(data)
+	}
+}
+
+func TestRequestsAreAuthorized(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("GET /api/v1/orgs/example/projects", serveFixture(t, http.StatusOK, "projects.json"))
+
+	if _, err := NewProjectsService(testApiKey, testOrgId, api.options()...).ListAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := api.requests[0].Header.Get("Authorization"), "Basic "+testApiKey; got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a proxy is sent the absolute url of the api rather than just its path
+		proxied = append(proxied, r.URL.String())
+		serveFixture(t, http.StatusOK, "projects.json")(w, r)
+	}))
+	defer proxy.Close()
+
+	proxyUrl, _ := url.Parse(proxy.URL)
+	apiUrl, _ := url.Parse("http://build-api.invalid/")
+
+	projects, err := NewProjectsService(testApiKey, testOrgId, WithProxy(proxyUrl), WithBaseURL(apiUrl), WithMaxRetries(0)).ListAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 2 {
+		t.Errorf("got %d projects through the proxy, want 2", len(projects))
+	}
+
+	if len(proxied) != 1 || !strings.HasPrefix(proxied[0], "http://build-api.invalid/api/v1/orgs/example/projects") {
+		t.Errorf("proxy got %v, want the projects request", proxied)
+	}
+}
@@ -0,0 +1,90 @@
+package cloudbuild
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const acceptEncoding = "gzip, deflate"
+
+// compressionTransport asks for gzip or deflate compressed responses and decompresses them, a response in any other
+// encoding is passed on untouched. Requests that already set Accept-Encoding, eg to identity, are left alone
+type compressionTransport struct {
+	next http.RoundTripper
+}
+
+func newCompressionTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &compressionTransport{next}
+}
+
+func (t *compressionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	// RoundTrip mustn't modify the request it is given
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip":
+		gz, err := gzip.NewReader(resp.Body)
+		if err == io.EOF {
+			// an empty body, eg of a 204, has no gzip header to read
+			return resp, nil
+		} else if err != nil {
+			resp.Body.Close()
+			return nil, err
+		}
+		body = gz
+	case "deflate":
+		body = newDeflateReader(resp.Body)
+	default:
+		return resp, nil
+	}
+
+	resp.Body = &decompressedBody{body, resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// newDeflateReader reads a deflate body, which should be zlib wrapped but some servers send raw deflate data
+func newDeflateReader(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if header, err := br.Peek(2); err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		if zr, err := zlib.NewReader(br); err == nil {
+			return zr
+		}
+	}
+	return flate.NewReader(br)
+}
+
+// decompressedBody reads the decompressed data and closes the original body
+type decompressedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+func (b *decompressedBody) Close() error {
+	if c, ok := b.Reader.(io.Closer); ok {
+		c.Close()
+	}
+	return b.body.Close()
+}
@@ -0,0 +1,61 @@
+package cloudbuild
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// serveCompressed answers with the projects fixture compressed by encoding, gzip or deflate
+func serveCompressed(t *testing.T, encoding string) http.HandlerFunc {
+	var body bytes.Buffer
+
+	var w io.WriteCloser
+	if encoding == "gzip" {
+		w = gzip.NewWriter(&body)
+	} else {
+		w, _ = flate.NewWriter(&body, flate.DefaultCompression)
+	}
+	w.Write(fixture(t, "projects.json"))
+	w.Close()
+
+	return func(rw http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept-Encoding"); got != acceptEncoding {
+			t.Errorf("Accept-Encoding = %q, want %q", got, acceptEncoding)
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Header().Set("Content-Encoding", encoding)
+		rw.Write(body.Bytes())
+	}
+}
+
+func TestCompressedResponses(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate"} {
+		api := newFakeApi(t)
+		api.handle("GET /api/v1/orgs/example/projects", serveCompressed(t, encoding))
+
+		projects, err := NewProjectsService(testApiKey, testOrgId, api.options()...).ListAll()
+		if err != nil {
+			t.Fatalf("%s: %v", encoding, err)
+		}
+		if len(projects) != 2 || projects[0].Id != "my-game" {
+			t.Errorf("%s: got %+v, want the decoded projects", encoding, projects)
+		}
+	}
+}
+
+func TestUncompressedResponse(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("GET /api/v1/orgs/example/projects", serveFixture(t, http.StatusOK, "projects.json"))
+
+	projects, err := NewProjectsService(testApiKey, testOrgId, api.options()...).ListAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 2 {
+		t.Errorf("got %d projects, want 2", len(projects))
+	}
+}
@@ -1,6 +1,7 @@
 package cloudbuild
 
 import (
+	"context"
 	"fmt"
 	"github.com/cmcpasserby/ucb/pkg/cloudbuild/responses"
 	"io"
@@ -9,55 +10,62 @@ import (
 	"strings"
 )
 
+// the form fields sent when uploading credentials, used to work out which one the api rejected
+var (
+	iosFormFields     = []string{"label", "fileCertificate", "fileProvisioningProfile", "certificatePass"}
+	androidFormFields = []string{"label", "fileKeystore", "storePass", "alias", "keyPass"}
+)
+
 type CredentialsService struct {
 	*client
 }
 
-func NewCredentialsService(apiKey, orgId string) *CredentialsService {
+func NewCredentialsService(apiKey, orgId string, opts ...Option) *CredentialsService {
 	return &CredentialsService{
-		client: newClient(apiKey, orgId),
+		client: newClient(apiKey, orgId, opts...),
 	}
 }
 
 func (c *CredentialsService) GetIOS(credId string) (*responses.IOSCred, error) {
+	return c.GetIOSContext(context.Background(), credId)
+}
+
+func (c *CredentialsService) GetIOSContext(ctx context.Context, credId string) (*responses.IOSCred, error) {
 	path := fmt.Sprintf("api/v1/orgs/%s/credentials/signing/ios/%s", c.OrgId, credId)
 
-	req, err := c.newRequest("GET", path, nil)
+	req, err := c.newRequest(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	var credential responses.IOSCred
-	resp, err := c.do(req, &credential)
-	if err != nil {
+	if _, err := c.do(req, &credential); err != nil {
 		return nil, err
 	}
 
-	fmt.Printf("status: %s\n", resp.Status)
-
 	return &credential, nil
 }
 
 func (c *CredentialsService) GetAllIOS() ([]responses.IOSCred, error) {
-	path := fmt.Sprintf("api/v1/orgs/%s/credentials/signing/ios", c.OrgId)
+	return c.GetAllIOSContext(context.Background())
+}
 
-	req, err := c.newRequest("GET", path, nil)
-	if err != nil {
-		return nil, err
-	}
+func (c *CredentialsService) GetAllIOSContext(ctx context.Context) ([]responses.IOSCred, error) {
+	path := fmt.Sprintf("api/v1/orgs/%s/credentials/signing/ios", c.OrgId)
 
 	var credentials []responses.IOSCred
-	resp, err := c.do(req, &credentials)
-	if err != nil {
+	if err := c.getAll(ctx, path, nil, &credentials); err != nil {
 		return nil, err
 	}
 
-	fmt.Printf("status: %s\n", resp.Status)
-
 	return credentials, nil
 }
 
 func (c *CredentialsService) UpdateIOS(certId, label, certPath, profilePath, certPass string) (*responses.IOSCred, error) {
+	return c.UpdateIOSContext(context.Background(), certId, label, certPath, profilePath, certPass)
+}
+
+func (c *CredentialsService) UpdateIOSContext(ctx context.Context, certId, label, certPath, profilePath, certPass string) (*responses.IOSCred, error) {
 	path := fmt.Sprintf("api/v1/orgs/%s/credentials/signing/ios/%s", c.OrgId, certId)
 
 	formData := map[string]io.Reader{
@@ -67,23 +75,49 @@ func (c *CredentialsService) UpdateIOS(certId, label, certPath, profilePath, cer
 		"certificatePass":         strings.NewReader(certPass),
 	}
 
-	req, err := c.newFormRequest("PUT", path, formData)
+	req, err := c.newFormRequest(ctx, "PUT", path, formData)
 	if err != nil {
 		return nil, err
 	}
 
 	var respData responses.IOSCred
-	resp, err := c.do(req, &respData)
+	if _, err := c.do(req, &respData); err != nil {
+		return nil, newUploadError(err, iosFormFields, map[string]string{
+			"fileCertificate":         certPath,
+			"fileProvisioningProfile": profilePath,
+		})
+	}
+
+	return &respData, nil
+}
+
+// RenameIOS changes only the label of a credential, the update form is sent without its signing files so those are
+// kept as they are
+func (c *CredentialsService) RenameIOS(certId, label string) (*responses.IOSCred, error) {
+	return c.RenameIOSContext(context.Background(), certId, label)
+}
+
+func (c *CredentialsService) RenameIOSContext(ctx context.Context, certId, label string) (*responses.IOSCred, error) {
+	path := fmt.Sprintf("api/v1/orgs/%s/credentials/signing/ios/%s", c.OrgId, certId)
+
+	req, err := c.newFormRequest(ctx, "PUT", path, map[string]io.Reader{"label": strings.NewReader(label)})
 	if err != nil {
 		return nil, err
 	}
 
-	fmt.Printf("status: %s\n", resp.Status)
+	var respData responses.IOSCred
+	if _, err := c.do(req, &respData); err != nil {
+		return nil, newUploadError(err, iosFormFields, nil)
+	}
 
 	return &respData, nil
 }
 
 func (c *CredentialsService) UploadIOS(label, certPath, profilePath, certPass string) (*responses.IOSCred, error) {
+	return c.UploadIOSContext(context.Background(), label, certPath, profilePath, certPass)
+}
+
+func (c *CredentialsService) UploadIOSContext(ctx context.Context, label, certPath, profilePath, certPass string) (*responses.IOSCred, error) {
 	path := fmt.Sprintf("api/v1/orgs/%s/credentials/signing/ios", c.OrgId)
 
 	formData := map[string]io.Reader{
@@ -93,26 +127,163 @@ func (c *CredentialsService) UploadIOS(label, certPath, profilePath, certPass st
 		"certificatePass":         strings.NewReader(certPass),
 	}
 
-	req, err := c.newFormRequest("POST", path, formData)
+	req, err := c.newFormRequest(ctx, "POST", path, formData)
 	if err != nil {
 		return nil, err
 	}
 
 	var respData responses.IOSCred
-	resp, err := c.do(req, &respData)
+	if _, err := c.do(req, &respData); err != nil {
+		return nil, newUploadError(err, iosFormFields, map[string]string{
+			"fileCertificate":         certPath,
+			"fileProvisioningProfile": profilePath,
+		})
+	}
+
+	return &respData, nil
+}
+
+func (c *CredentialsService) DeleteIOS(certId string) (*http.Response, error) {
+	return c.DeleteIOSContext(context.Background(), certId)
+}
+
+func (c *CredentialsService) DeleteIOSContext(ctx context.Context, certId string) (*http.Response, error) {
+	path := fmt.Sprintf("api/v1/orgs/%s/credentials/signing/ios/%s", c.OrgId, certId)
+
+	req, err := c.newRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (c *CredentialsService) GetAndroid(credId string) (*responses.AndroidCred, error) {
+	return c.GetAndroidContext(context.Background(), credId)
+}
+
+func (c *CredentialsService) GetAndroidContext(ctx context.Context, credId string) (*responses.AndroidCred, error) {
+	path := fmt.Sprintf("api/v1/orgs/%s/credentials/signing/android/%s", c.OrgId, credId)
+
+	req, err := c.newRequest(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	fmt.Printf("status %s\n", resp.Status)
+	var credential responses.AndroidCred
+	if _, err := c.do(req, &credential); err != nil {
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+func (c *CredentialsService) GetAllAndroid() ([]responses.AndroidCred, error) {
+	return c.GetAllAndroidContext(context.Background())
+}
+
+func (c *CredentialsService) GetAllAndroidContext(ctx context.Context) ([]responses.AndroidCred, error) {
+	path := fmt.Sprintf("api/v1/orgs/%s/credentials/signing/android", c.OrgId)
+
+	var credentials []responses.AndroidCred
+	if err := c.getAll(ctx, path, nil, &credentials); err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+func (c *CredentialsService) UpdateAndroid(credId, label, keystorePath, keystorePass, keyAlias, keyPass string) (*responses.AndroidCred, error) {
+	return c.UpdateAndroidContext(context.Background(), credId, label, keystorePath, keystorePass, keyAlias, keyPass)
+}
+
+func (c *CredentialsService) UpdateAndroidContext(ctx context.Context, credId, label, keystorePath, keystorePass, keyAlias, keyPass string) (*responses.AndroidCred, error) {
+	path := fmt.Sprintf("api/v1/orgs/%s/credentials/signing/android/%s", c.OrgId, credId)
+
+	formData := map[string]io.Reader{
+		"label":        strings.NewReader(label),
+		"fileKeystore": mustOpen(keystorePath),
+		"storePass":    strings.NewReader(keystorePass),
+		"alias":        strings.NewReader(keyAlias),
+		"keyPass":      strings.NewReader(keyPass),
+	}
+
+	req, err := c.newFormRequest(ctx, "PUT", path, formData)
+	if err != nil {
+		return nil, err
+	}
+
+	var respData responses.AndroidCred
+	if _, err := c.do(req, &respData); err != nil {
+		return nil, newUploadError(err, androidFormFields, map[string]string{"fileKeystore": keystorePath})
+	}
 
 	return &respData, nil
 }
 
-func (c *CredentialsService) DeleteIOS(certId string) (*http.Response, error) {
-	path := fmt.Sprintf("api/v1/orgs/%s/credentials/signing/ios/%s", c.OrgId, certId)
+// RenameAndroid changes only the label of a credential, the update form is sent without its keystore so that is kept
+// as it is
+func (c *CredentialsService) RenameAndroid(credId, label string) (*responses.AndroidCred, error) {
+	return c.RenameAndroidContext(context.Background(), credId, label)
+}
+
+func (c *CredentialsService) RenameAndroidContext(ctx context.Context, credId, label string) (*responses.AndroidCred, error) {
+	path := fmt.Sprintf("api/v1/orgs/%s/credentials/signing/android/%s", c.OrgId, credId)
+
+	req, err := c.newFormRequest(ctx, "PUT", path, map[string]io.Reader{"label": strings.NewReader(label)})
+	if err != nil {
+		return nil, err
+	}
+
+	var respData responses.AndroidCred
+	if _, err := c.do(req, &respData); err != nil {
+		return nil, newUploadError(err, androidFormFields, nil)
+	}
+
+	return &respData, nil
+}
+
+func (c *CredentialsService) UploadAndroid(label, keystorePath, keystorePass, keyAlias, keyPass string) (*responses.AndroidCred, error) {
+	return c.UploadAndroidContext(context.Background(), label, keystorePath, keystorePass, keyAlias, keyPass)
+}
+
+func (c *CredentialsService) UploadAndroidContext(ctx context.Context, label, keystorePath, keystorePass, keyAlias, keyPass string) (*responses.AndroidCred, error) {
+	path := fmt.Sprintf("api/v1/orgs/%s/credentials/signing/android", c.OrgId)
+
+	formData := map[string]io.Reader{
+		"label":        strings.NewReader(label),
+		"fileKeystore": mustOpen(keystorePath),
+		"storePass":    strings.NewReader(keystorePass),
+		"alias":        strings.NewReader(keyAlias),
+		"keyPass":      strings.NewReader(keyPass),
+	}
+
+	req, err := c.newFormRequest(ctx, "POST", path, formData)
+	if err != nil {
+		return nil, err
+	}
+
+	var respData responses.AndroidCred
+	if _, err := c.do(req, &respData); err != nil {
+		return nil, newUploadError(err, androidFormFields, map[string]string{"fileKeystore": keystorePath})
+	}
+
+	return &respData, nil
+}
+
+func (c *CredentialsService) DeleteAndroid(credId string) (*http.Response, error) {
+	return c.DeleteAndroidContext(context.Background(), credId)
+}
+
+func (c *CredentialsService) DeleteAndroidContext(ctx context.Context, credId string) (*http.Response, error) {
+	path := fmt.Sprintf("api/v1/orgs/%s/credentials/signing/android/%s", c.OrgId, credId)
 
-	req, err := c.newRequest("DELETE", path, nil)
+	req, err := c.newRequest(ctx, "DELETE", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,135 @@
+package cloudbuild
+
+import (
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetAllIOS(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("GET /api/v1/orgs/example/credentials/signing/ios", serveFixture(t, http.StatusOK, "ios_creds.json"))
+
+	creds, err := NewCredentialsService(testApiKey, testOrgId, api.options()...).GetAllIOS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(creds) != 2 {
+		t.Fatalf("got %d credentials, want 2", len(creds))
+	}
+
+	cred := creds[0]
+	if cred.Id != "0a1b2c3d-4e5f-6789-abcd-ef0123456789" || cred.Label != "release" {
+		t.Errorf("got credential %s %q, want 0a1b2c3d-4e5f-6789-abcd-ef0123456789 \"release\"", cred.Id, cred.Label)
+	}
+	if !cred.Certificate.IsDistribution || cred.ProvisioningProfile.BundleID != "com.example.game" {
+		t.Errorf("certificate and profile weren't decoded: %+v", cred)
+	}
+	if want := time.Date(2020, 12, 2, 3, 4, 5, 0, time.UTC); !cred.Expiration().Equal(want) {
+		t.Errorf("Expiration() = %v, want %v", cred.Expiration(), want)
+	}
+	if creds[1].ProvisioningProfile.NumDevices != 3 {
+		t.Errorf("NumDevices = %d, want 3", creds[1].ProvisioningProfile.NumDevices)
+	}
+}
+
+func TestGetAllIOSError(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("GET /api/v1/orgs/example/credentials/signing/ios", serveFixture(t, http.StatusUnauthorized, "error_unauthorized.json"))
+
+	creds, err := NewCredentialsService(testApiKey, testOrgId, api.options()...).GetAllIOS()
+	if creds != nil {
+		t.Errorf("got credentials %v with an error", creds)
+	}
+
+	authErr, ok := err.(*AuthError)
+	if !ok {
+		t.Fatalf("got %T %v, want *AuthError", err, err)
+	}
+	if authErr.StatusCode != http.StatusUnauthorized || authErr.Message != "invalid api key" {
+		t.Errorf("got %d %q, want 401 \"invalid api key\"", authErr.StatusCode, authErr.Message)
+	}
+}
+
+func TestGetIOS(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("GET /api/v1/orgs/example/credentials/signing/ios/0a1b2c3d-4e5f-6789-abcd-ef0123456789", serveFixture(t, http.StatusOK, "ios_cred.json"))
+
+	cred, err := NewCredentialsService(testApiKey, testOrgId, api.options()...).GetIOS("0a1b2c3d-4e5f-6789-abcd-ef0123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cred.Label != "release" || cred.Certificate.TeamId != "ABCDE12345" || cred.ProvisioningProfile.Type != "appstore" {
+		t.Errorf("credential wasn't decoded: %+v", cred)
+	}
+}
+
+func TestGetIOSNotFound(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("GET /api/v1/orgs/example/credentials/signing/ios/missing", serveFixture(t, http.StatusNotFound, "error_not_found.json"))
+
+	cred, err := NewCredentialsService(testApiKey, testOrgId, api.options()...).GetIOS("missing")
+	if cred != nil {
+		t.Errorf("got credential %v with an error", cred)
+	}
+
+	notFound, ok := err.(*NotFoundError)
+	if !ok {
+		t.Fatalf("got %T %v, want *NotFoundError", err, err)
+	}
+	if notFound.Message != "credential not found" {
+		t.Errorf("Message = %q, want \"credential not found\"", notFound.Message)
+	}
+}
+
+func TestUploadIOSRejected(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "dist.p12")
+	profilePath := filepath.Join(dir, "dist.mobileprovision")
+	for _, path := range []string{certPath, profilePath} {
+		if err := ioutil.WriteFile(path, []byte("not signing data"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	api := newFakeApi(t)
+	api.handle("POST /api/v1/orgs/example/credentials/signing/ios", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("upload isn't a multipart form: %v", err)
+		}
+		if label := r.FormValue("label"); label != "release" {
+			t.Errorf("label = %q, want release", label)
+		}
+		if _, _, err := r.FormFile("fileCertificate"); err != nil {
+			t.Errorf("no fileCertificate: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"error":"could not read the p12 file","field":"fileCertificate"}`))
+	})
+
+	cred, err := NewCredentialsService(testApiKey, testOrgId, api.options()...).UploadIOS("release", certPath, profilePath, "secret")
+	if cred != nil {
+		t.Errorf("got credential %v with an error", cred)
+	}
+
+	uploadErr, ok := err.(*UploadError)
+	if !ok {
+		t.Fatalf("got %T %v, want *UploadError", err, err)
+	}
+	if uploadErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("StatusCode = %d, want 422", uploadErr.StatusCode)
+	}
+	if uploadErr.Field != "fileCertificate" || uploadErr.File != certPath {
+		t.Errorf("got field %q file %q, want fileCertificate %s", uploadErr.Field, uploadErr.File, certPath)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "could not read the p12 file") || !strings.Contains(msg, certPath) {
+		t.Errorf("error %q is missing the api's message or the file", msg)
+	}
+}
@@ -0,0 +1,114 @@
+package cloudbuild
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ErrDryRun is returned in place of the response for requests that were only printed because of WithDryRun
+var ErrDryRun = errors.New("dry run, request not sent")
+
+// dryRunTransport prints requests that would modify data instead of sending them, reads are still sent
+// so commands can resolve their arguments
+type dryRunTransport struct {
+	next http.RoundTripper
+	out  io.Writer
+}
+
+func newDryRunTransport(next http.RoundTripper, out io.Writer) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	if out == nil {
+		return next
+	}
+
+	return &dryRunTransport{next, out}
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == "GET" || req.Method == "HEAD" {
+		return t.next.RoundTrip(req)
+	}
+
+	fmt.Fprintf(t.out, "dry run: %s %s\n", req.Method, req.URL)
+
+	if req.Body != nil {
+		defer req.Body.Close()
+
+		// values of env vars are often secrets
+		redactAll := strings.HasSuffix(req.URL.Path, "/envvars")
+
+		fields, err := requestFields(req, redactAll)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, field := range fields {
+			fmt.Fprintf(t.out, "  %s\n", field)
+		}
+	}
+
+	return nil, ErrDryRun
+}
+
+// requestFields describes each field of a json or multipart request body
+func requestFields(req *http.Request, redactAll bool) ([]string, error) {
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+
+	var fields []string
+
+	switch mediaType {
+	case "multipart/form-data":
+		r := multipart.NewReader(req.Body, params["boundary"])
+		for {
+			part, err := r.NextPart()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+
+			data, err := ioutil.ReadAll(part)
+			if err != nil {
+				return nil, err
+			}
+
+			name := part.FormName()
+			switch {
+			case part.FileName() != "":
+				fields = append(fields, fmt.Sprintf("%s: <file %s, %d bytes>", name, part.FileName(), len(data)))
+			case redactAll || IsSecretField(name):
+				fields = append(fields, fmt.Sprintf("%s: %s", name, Redacted))
+			default:
+				fields = append(fields, fmt.Sprintf("%s: %s", name, data))
+			}
+		}
+
+	case "application/json":
+		var body map[string]json.RawMessage
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			return nil, err
+		}
+
+		for name, value := range body {
+			if redactAll || IsSecretField(name) {
+				fields = append(fields, fmt.Sprintf("%s: %s", name, Redacted))
+			} else {
+				fields = append(fields, fmt.Sprintf("%s: %s", name, value))
+			}
+		}
+	}
+
+	sort.Strings(fields)
+	return fields, nil
+}
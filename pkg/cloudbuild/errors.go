@@ -0,0 +1,173 @@
+package cloudbuild
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ResponseError is returned when the api responds with an error status
+type ResponseError struct {
+	StatusCode int
+	Status     string
+	Message    string // the message from the api's error body, empty if it didn't send one
+	Body       string
+}
+
+func (e *ResponseError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s: %s", e.Status, e.Message)
+	}
+
+	if body := strings.TrimSpace(e.Body); body != "" {
+		return fmt.Sprintf("%s: %s", e.Status, body)
+	}
+	return fmt.Sprintf("request failed: %s", e.Status)
+}
+
+// errorMessage reads the message from an api error body such as {"error":"invalid credential"}
+func errorMessage(body []byte) string {
+	var payload struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+
+	if payload.Error != "" {
+		return payload.Error
+	}
+	return payload.Message
+}
+
+// AuthError is returned when the api rejects the api key or it can't access the resource, a 401 or 403
+type AuthError struct {
+	*ResponseError
+}
+
+// NotFoundError is returned when the requested resource does not exist, a 404
+type NotFoundError struct {
+	*ResponseError
+}
+
+func newResponseError(resp *http.Response, body []byte) error {
+	respErr := &ResponseError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Message:    errorMessage(body),
+		Body:       string(body),
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &AuthError{respErr}
+	case http.StatusNotFound:
+		return &NotFoundError{respErr}
+	case http.StatusTooManyRequests:
+		return newRateLimitError(resp, body)
+	default:
+		return respErr
+	}
+}
+
+// UploadError is returned when the api rejects the files or values of a credential upload
+type UploadError struct {
+	*ResponseError
+	Field string // the form field the api complained about, empty if it couldn't be determined
+	File  string // the local file sent in Field, empty if Field isn't a file
+}
+
+func (e *UploadError) Error() string {
+	msg := fmt.Sprintf("credential upload rejected, %s", e.ResponseError.Error())
+	switch {
+	case e.File != "":
+		return fmt.Sprintf("%s (%s: %s)", msg, e.Field, e.File)
+	case e.Field != "":
+		return fmt.Sprintf("%s (%s)", msg, e.Field)
+	default:
+		return msg
+	}
+}
+
+// newUploadError adds the field the api complained about to a plain ResponseError from an upload, files maps the
+// form fields to the local paths that were sent in them
+func newUploadError(err error, fields []string, files map[string]string) error {
+	respErr, ok := err.(*ResponseError)
+	if !ok {
+		return err
+	}
+
+	field := complainedField([]byte(respErr.Body), respErr.Message, fields)
+	return &UploadError{respErr, field, files[field]}
+}
+
+// complainedField finds which of fields an api error body refers to, either by a field or param key, as a key of
+// an errors or details object, or by name in the message
+func complainedField(body []byte, message string, fields []string) string {
+	var payload struct {
+		Field   string                     `json:"field"`
+		Param   string                     `json:"param"`
+		Errors  map[string]json.RawMessage `json:"errors"`
+		Details map[string]json.RawMessage `json:"details"`
+	}
+	json.Unmarshal(body, &payload)
+
+	for _, field := range fields {
+		if payload.Field == field || payload.Param == field {
+			return field
+		}
+		if _, ok := payload.Errors[field]; ok {
+			return field
+		}
+		if _, ok := payload.Details[field]; ok {
+			return field
+		}
+	}
+
+	for _, field := range fields {
+		if strings.Contains(message, field) {
+			return field
+		}
+	}
+	return ""
+}
+
+// RateLimitError is returned when the api responds with 429 Too Many Requests
+type RateLimitError struct {
+	RetryAfter time.Duration // zero when the api did not say how long to wait
+	Limit      int           // -1 when the api did not report its quota
+	Remaining  int           // -1 when the api did not report its quota
+	Body       string
+}
+
+func newRateLimitError(resp *http.Response, body []byte) *RateLimitError {
+	retryAfter, _ := parseRetryAfter(resp.Header.Get("Retry-After"))
+
+	return &RateLimitError{
+		RetryAfter: retryAfter,
+		Limit:      headerInt(resp.Header, "X-RateLimit-Limit"),
+		Remaining:  headerInt(resp.Header, "X-RateLimit-Remaining"),
+		Body:       string(body),
+	}
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter <= 0 {
+		return "rate limited, retry later"
+	}
+	return fmt.Sprintf("rate limited, retry in %ds", int(math.Ceil(e.RetryAfter.Seconds())))
+}
+
+func headerInt(h http.Header, key string) int {
+	v, err := strconv.Atoi(h.Get(key))
+	if err != nil {
+		return -1
+	}
+	return v
+}
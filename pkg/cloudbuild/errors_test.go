@@ -0,0 +1,41 @@
+package cloudbuild
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestErrorBodyMessage(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("GET /api/v1/orgs/example/credentials/signing/ios/bad", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid credential"}`))
+	})
+
+	_, err := NewCredentialsService(testApiKey, testOrgId, api.options()...).GetIOS("bad")
+
+	respErr, ok := err.(*ResponseError)
+	if !ok {
+		t.Fatalf("got %T %v, want *ResponseError", err, err)
+	}
+	if respErr.StatusCode != http.StatusBadRequest || respErr.Message != "invalid credential" {
+		t.Errorf("got %d %q, want 400 \"invalid credential\"", respErr.StatusCode, respErr.Message)
+	}
+	if !strings.Contains(err.Error(), "invalid credential") {
+		t.Errorf("error %q doesn't include the api's message", err)
+	}
+}
+
+func TestErrorBodyNotJSON(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("GET /api/v1/orgs/example/projects", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "upstream unavailable", http.StatusBadGateway)
+	})
+
+	_, err := NewProjectsService(testApiKey, testOrgId, api.options()...).ListAll()
+	if err == nil || !strings.Contains(err.Error(), "upstream unavailable") {
+		t.Errorf("got %v, want the body in the error", err)
+	}
+}
@@ -0,0 +1,144 @@
+package cloudbuild
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelError = "error"
+)
+
+// LogEntry describes a request being sent or its response, Status and Duration are only set for responses
+type LogEntry struct {
+	Time     time.Time
+	Level    string
+	Method   string
+	URL      string
+	Headers  map[string]string // request headers with secrets redacted
+	Status   int
+	Duration time.Duration
+	Err      error
+}
+
+// Logger receives an entry for every request a service makes and every response it gets
+type Logger interface {
+	Log(entry LogEntry)
+}
+
+type textLogger struct {
+	logger *log.Logger
+}
+
+func (l textLogger) Log(entry LogEntry) {
+	switch {
+	case entry.Err != nil:
+		l.logger.Printf("<-- %s %s failed after %s: %v", entry.Method, entry.URL, entry.Duration, entry.Err)
+	case entry.Status != 0:
+		l.logger.Printf("<-- %d %s %s (%s)", entry.Status, http.StatusText(entry.Status), entry.URL, entry.Duration)
+	default:
+		l.logger.Printf("--> %s %s", entry.Method, entry.URL)
+
+		keys := make([]string, 0, len(entry.Headers))
+		for key := range entry.Headers {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			l.logger.Printf("    %s: %s", key, entry.Headers[key])
+		}
+	}
+}
+
+type jsonLogger struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONLogger writes each entry to w as a single line json object
+func NewJSONLogger(w io.Writer) Logger {
+	return &jsonLogger{enc: json.NewEncoder(w)}
+}
+
+func (l *jsonLogger) Log(entry LogEntry) {
+	line := struct {
+		Time     string            `json:"timestamp"`
+		Level    string            `json:"level"`
+		Method   string            `json:"method"`
+		URL      string            `json:"url"`
+		Headers  map[string]string `json:"headers,omitempty"`
+		Status   int               `json:"status,omitempty"`
+		Duration float64           `json:"durationMs,omitempty"`
+		Error    string            `json:"error,omitempty"`
+	}{
+		Time:     entry.Time.UTC().Format(time.RFC3339Nano),
+		Level:    entry.Level,
+		Method:   entry.Method,
+		URL:      entry.URL,
+		Headers:  entry.Headers,
+		Status:   entry.Status,
+		Duration: float64(entry.Duration) / float64(time.Millisecond),
+	}
+	if entry.Err != nil {
+		line.Error = entry.Err.Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.enc.Encode(line)
+}
+
+// loggingTransport logs each request and its response, secret headers are redacted
+type loggingTransport struct {
+	next   http.RoundTripper
+	logger Logger
+}
+
+func newLoggingTransport(next http.RoundTripper, logger Logger) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	if logger == nil {
+		return next
+	}
+
+	return &loggingTransport{next, logger}
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	headers := make(map[string]string, len(req.Header))
+	for key := range req.Header {
+		value := req.Header.Get(key)
+		if IsSecretField(key) {
+			value = Redacted
+		}
+		headers[key] = value
+	}
+
+	start := time.Now()
+	t.logger.Log(LogEntry{Time: start, Level: LevelDebug, Method: req.Method, URL: req.URL.String(), Headers: headers})
+
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	if err != nil {
+		t.logger.Log(LogEntry{Time: time.Now(), Level: LevelError, Method: req.Method, URL: req.URL.String(), Duration: elapsed, Err: err})
+		return resp, err
+	}
+
+	level := LevelInfo
+	if resp.StatusCode >= 400 {
+		level = LevelError
+	}
+	t.logger.Log(LogEntry{Time: time.Now(), Level: level, Method: req.Method, URL: req.URL.String(), Status: resp.StatusCode, Duration: elapsed})
+	return resp, nil
+}
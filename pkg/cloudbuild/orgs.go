@@ -0,0 +1,98 @@
+package cloudbuild
+
+import (
+	"context"
+	"fmt"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild/responses"
+	"strings"
+	"time"
+)
+
+type OrgService struct {
+	*client
+}
+
+func NewOrgService(apiKey, orgId string, opts ...Option) *OrgService {
+	return &OrgService{
+		client: newClient(apiKey, orgId, opts...),
+	}
+}
+
+// CurrentUser returns the user the api key belongs to
+func (c *OrgService) CurrentUser() (*responses.User, error) {
+	return c.CurrentUserContext(context.Background())
+}
+
+func (c *OrgService) CurrentUserContext(ctx context.Context) (*responses.User, error) {
+	req, err := c.newRequest(ctx, "GET", "api/v1/users/me", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var user responses.User
+	if _, err := c.do(req, &user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// Name returns the display name of the org, this also checks the api key has access to it. The name is read from
+// the org's projects so it is empty for an org without any
+func (c *OrgService) Name() (string, error) {
+	return c.NameContext(context.Background())
+}
+
+func (c *OrgService) NameContext(ctx context.Context) (string, error) {
+	path := fmt.Sprintf("api/v1/orgs/%s/projects", c.OrgId)
+
+	var projects []responses.Project
+	if err := c.getN(ctx, path, nil, 1, &projects); err != nil {
+		return "", err
+	}
+
+	if len(projects) == 0 {
+		return "", nil
+	}
+	return projects[0].OrgName, nil
+}
+
+// AuditLogOptions filters the entries returned by GetAuditLog
+type AuditLogOptions struct {
+	Since time.Time // only return entries created at or after this time, zero has no lower bound
+	Until time.Time // only return entries created before this time, zero has no upper bound
+	Actor string    // only return entries made by this user, matched case insensitively, empty returns all
+}
+
+func (o AuditLogOptions) match(entry responses.AuditEntry) bool {
+	if !o.Since.IsZero() && entry.Created.Before(o.Since) {
+		return false
+	}
+	if !o.Until.IsZero() && !entry.Created.Before(o.Until) {
+		return false
+	}
+	return o.Actor == "" || strings.EqualFold(o.Actor, entry.User)
+}
+
+// GetAuditLog returns every page of the org's audit log that matches opts
+func (c *OrgService) GetAuditLog(opts AuditLogOptions) ([]responses.AuditEntry, error) {
+	return c.GetAuditLogContext(context.Background(), opts)
+}
+
+func (c *OrgService) GetAuditLogContext(ctx context.Context, opts AuditLogOptions) ([]responses.AuditEntry, error) {
+	path := fmt.Sprintf("api/v1/orgs/%s/auditlog", c.OrgId)
+
+	var entries []responses.AuditEntry
+	if err := c.getAll(ctx, path, nil, &entries); err != nil {
+		return nil, err
+	}
+
+	matching := make([]responses.AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		if opts.match(entry) {
+			matching = append(matching, entry)
+		}
+	}
+
+	return matching, nil
+}
@@ -0,0 +1,88 @@
+package cloudbuild
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+// DefaultPageSize is how many results each request for a page of a list asks for when no WithPageSize is given
+const DefaultPageSize = 25
+
+// MaxPageSize is the most results the api returns per page, larger page sizes are lowered to it
+const MaxPageSize = 100
+
+var linkNextRe = regexp.MustCompile(`<([^>]+)>\s*;\s*rel="?next"?`)
+
+// getAll requests every page of a list endpoint, following the Link header, and stores the combined results in v
+// which must be a pointer to a slice
+func (c *client) getAll(ctx context.Context, path string, query url.Values, v interface{}) error {
+	return c.getN(ctx, path, query, 0, v)
+}
+
+// getN is getAll but stops once limit results have been fetched, a limit of 0 fetches everything
+func (c *client) getN(ctx context.Context, path string, query url.Values, limit int, v interface{}) error {
+	out := reflect.ValueOf(v)
+	if out.Kind() != reflect.Ptr || out.Elem().Kind() != reflect.Slice {
+		return errors.New("getAll requires a pointer to a slice")
+	}
+	sliceType := out.Elem().Type()
+
+	pageSize := c.pageSize
+	if limit > 0 && limit < pageSize {
+		pageSize = limit
+	}
+
+	pageQuery := url.Values{}
+	for key, values := range query {
+		pageQuery[key] = values
+	}
+	pageQuery.Set("per_page", strconv.Itoa(pageSize))
+	pageQuery.Set("page", "1")
+
+	next := c.BaseUrl.ResolveReference(&url.URL{Path: path, RawQuery: pageQuery.Encode()})
+	seen := make(map[string]bool)
+
+	for next != nil && !seen[next.String()] {
+		seen[next.String()] = true
+
+		req, err := c.newRequestURL(ctx, "GET", next.String(), nil)
+		if err != nil {
+			return err
+		}
+
+		page := reflect.New(sliceType)
+		resp, err := c.do(req, page.Interface())
+		if err != nil {
+			return err
+		}
+
+		out.Elem().Set(reflect.AppendSlice(out.Elem(), page.Elem()))
+
+		if limit > 0 && out.Elem().Len() >= limit {
+			out.Elem().Set(out.Elem().Slice(0, limit))
+			break
+		}
+
+		next = c.nextPage(resp)
+	}
+
+	return nil
+}
+
+func (c *client) nextPage(resp *http.Response) *url.URL {
+	match := linkNextRe.FindStringSubmatch(resp.Header.Get("Link"))
+	if match == nil {
+		return nil
+	}
+
+	u, err := url.Parse(match[1])
+	if err != nil {
+		return nil
+	}
+	return c.BaseUrl.ResolveReference(u)
+}
@@ -0,0 +1,69 @@
+package cloudbuild
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestGetAllIOSPages(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("GET /api/v1/orgs/example/credentials/signing/ios", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch page := r.URL.Query().Get("page"); page {
+		case "1":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/api/v1/orgs/example/credentials/signing/ios?per_page=2&page=2>; rel="next"`, api.URL))
+			fmt.Fprint(w, `[{"label":"one","credentialid":"1"},{"label":"two","credentialid":"2"}]`)
+		case "2":
+			w.Header().Set("Link", fmt.Sprintf(`<%s/api/v1/orgs/example/credentials/signing/ios?per_page=2&page=1>; rel="prev"`, api.URL))
+			fmt.Fprint(w, `[{"label":"three","credentialid":"3"}]`)
+		default:
+			t.Errorf("requested page %q", page)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+
+	creds, err := NewCredentialsService(testApiKey, testOrgId, api.options(WithPageSize(2))...).GetAllIOS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var labels []string
+	for _, cred := range creds {
+		labels = append(labels, cred.Label)
+	}
+	if fmt.Sprint(labels) != "[one two three]" {
+		t.Errorf("got %v, want [one two three]", labels)
+	}
+
+	if len(api.requests) != 2 {
+		t.Fatalf("made %d requests, want 2", len(api.requests))
+	}
+	if perPage := api.requests[0].URL.Query().Get("per_page"); perPage != "2" {
+		t.Errorf("per_page = %q, want 2", perPage)
+	}
+}
+
+func TestListAllPages(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("GET /api/v1/orgs/example/projects", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.URL.Query().Get("page") == "1" {
+			// a relative link is resolved against the base url
+			w.Header().Set("Link", `</api/v1/orgs/example/projects?page=2>; rel=next`)
+			fmt.Fprint(w, `[{"projectId":"a"}]`)
+		} else {
+			fmt.Fprint(w, `[{"projectId":"b"}]`)
+		}
+	})
+
+	projects, err := NewProjectsService(testApiKey, testOrgId, api.options()...).ListAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 2 || projects[0].Id != "a" || projects[1].Id != "b" {
+		t.Errorf("got %+v, want projects a and b", projects)
+	}
+}
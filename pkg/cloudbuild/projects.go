@@ -1,35 +1,78 @@
 package cloudbuild
 
 import (
+	"context"
 	"fmt"
 	"github.com/cmcpasserby/ucb/pkg/cloudbuild/responses"
+	"sync"
 )
 
 type ProjectsService struct {
 	*client
 }
 
-func NewProjectsService(apiKey, orgId string) *ProjectsService {
+func NewProjectsService(apiKey, orgId string, opts ...Option) *ProjectsService {
 	return &ProjectsService{
-		client: newClient(apiKey, orgId),
+		client: newClient(apiKey, orgId, opts...),
 	}
 }
 
 func (c *ProjectsService) ListAll() ([]responses.Project, error) {
+	return c.ListAllContext(context.Background())
+}
+
+func (c *ProjectsService) ListAllContext(ctx context.Context) ([]responses.Project, error) {
 	path := fmt.Sprintf("api/v1/orgs/%s/projects", c.OrgId)
 
-	req, err := c.newRequest("GET", path, nil)
-	if err != nil {
+	var projects []responses.Project
+	if err := c.getAll(ctx, path, nil, &projects); err != nil {
 		return nil, err
 	}
 
-	var projects []responses.Project
-	resp, err := c.do(req, &projects)
-	if err != nil {
-		return nil, err
+	return projects, nil
+}
+
+// ProjectResult is the outcome of fetching a resource for a single project
+type ProjectResult struct {
+	ProjectId string
+	Value     interface{}
+	Err       error
+}
+
+// FetchFunc fetches a resource for a single project
+type FetchFunc func(ctx context.Context, projectId string) (interface{}, error)
+
+// ForEachProject calls fetch for every project id using at most concurrency workers, the results are in the
+// same order as projectIds and a failing project does not stop the others
+func ForEachProject(ctx context.Context, projectIds []string, concurrency int, fetch FetchFunc) []ProjectResult {
+	if concurrency < 1 {
+		concurrency = 1
 	}
 
-	fmt.Printf("status: %s\n", resp.Status)
+	results := make([]ProjectResult, len(projectIds))
+	jobs := make(chan int)
 
-	return projects, nil
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency && i < len(projectIds); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx].ProjectId = projectIds[idx]
+				if err := ctx.Err(); err != nil {
+					results[idx].Err = err
+					continue
+				}
+				results[idx].Value, results[idx].Err = fetch(ctx, projectIds[idx])
+			}
+		}()
+	}
+
+	for i := range projectIds {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
 }
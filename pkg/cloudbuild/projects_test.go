@@ -0,0 +1,42 @@
+package cloudbuild
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestListAll(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("GET /api/v1/orgs/example/projects", serveFixture(t, http.StatusOK, "projects.json"))
+
+	projects, err := NewProjectsService(testApiKey, testOrgId, api.options()...).ListAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(projects) != 2 {
+		t.Fatalf("got %d projects, want 2", len(projects))
+	}
+	if projects[0].Id != "my-game" || projects[0].Name != "My Game" || !projects[0].GenerateShareLinks {
+		t.Errorf("project wasn't decoded: %+v", projects[0])
+	}
+	if href := projects[0].Links["self"].Href; href != "/api/v1/orgs/example/projects/my-game" {
+		t.Errorf("self link = %q", href)
+	}
+	if !projects[1].Disabled {
+		t.Errorf("other-game should be disabled")
+	}
+}
+
+func TestListAllError(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("GET /api/v1/orgs/example/projects", serveFixture(t, http.StatusForbidden, "error_unauthorized.json"))
+
+	projects, err := NewProjectsService(testApiKey, testOrgId, api.options()...).ListAll()
+	if projects != nil {
+		t.Errorf("got projects %v with an error", projects)
+	}
+	if _, ok := err.(*AuthError); !ok {
+		t.Fatalf("got %T %v, want *AuthError", err, err)
+	}
+}
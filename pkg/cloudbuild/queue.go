@@ -0,0 +1,141 @@
+package cloudbuild
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ErrQueued is returned in place of the response for requests that couldn't reach the api and were handed to the
+// queue given to WithOfflineQueue
+var ErrQueued = errors.New("could not reach the api, the request was queued to be sent later")
+
+// QueuedRequest is a request that changes data but couldn't reach the api, with everything needed to send it again
+// other than the api key, its Authorization header is left out and Replay adds it back
+type QueuedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body,omitempty"`
+	Queued time.Time   `json:"queued"`
+}
+
+// WithOfflineQueue hands requests that would change data to queue when they fail to connect, after any retries,
+// instead of failing with the connection error. They fail with ErrQueued, or the error from queue, and Replay sends
+// them again once the api can be reached. Only requests that never reached the api are queued, and never ones with
+// secrets in their body such as credential uploads, those fail with their error as usual
+func WithOfflineQueue(queue func(QueuedRequest) error) Option {
+	return func(c *client) {
+		c.queue = queue
+	}
+}
+
+type unqueuedKey struct{}
+
+// withoutQueue marks a request whose body holds secrets, eg signing files and their passwords or env var values, so
+// it is never written to the offline queue
+func withoutQueue(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), unqueuedKey{}, true))
+}
+
+// queueTransport saves requests that change data when they can't be sent
+type queueTransport struct {
+	next  http.RoundTripper
+	queue func(QueuedRequest) error
+}
+
+func newQueueTransport(next http.RoundTripper, queue func(QueuedRequest) error) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	if queue == nil {
+		return next
+	}
+
+	return &queueTransport{next, queue}
+}
+
+func (t *queueTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == "GET" || req.Method == "HEAD" || req.Context().Value(unqueuedKey{}) != nil {
+		return t.next.RoundTrip(req)
+	}
+
+	// the body is kept so it can be queued after the retries have read it
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err == nil || !notSent(err) || req.Context().Err() != nil {
+		return resp, err
+	}
+
+	header := req.Header.Clone()
+	header.Del("Authorization")
+
+	queued := QueuedRequest{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: header,
+		Body:   body,
+		Queued: time.Now(),
+	}
+	if err := t.queue(queued); err != nil {
+		return nil, err
+	}
+	return nil, ErrQueued
+}
+
+// notSent reports whether err is from failing to connect, a dns lookup or dial error, where the request can't have
+// reached the api. Anything later, eg a timeout waiting for the response, may have been applied already so sending
+// it again could make a duplicate
+func notSent(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && (opErr.Op == "dial" || opErr.Op == "proxyconnect")
+}
+
+// Replay sends a queued request again with apiKey using a client configured by opts, the request's own url is used
+// so the base url opts set doesn't apply
+func Replay(ctx context.Context, apiKey string, queued QueuedRequest, opts ...Option) error {
+	c := newClient(apiKey, "", opts...)
+
+	req, err := http.NewRequest(queued.Method, queued.URL, bytes.NewReader(queued.Body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header = queued.Header.Clone()
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Basic %s", c.ApiKey))
+
+	resp, err := c.doStream(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
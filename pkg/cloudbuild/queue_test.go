@@ -0,0 +1,91 @@
+package cloudbuild
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// closedApi returns options for an api that refuses connections
+func closedApi(t *testing.T, opts ...Option) []Option {
+	api := newFakeApi(t)
+	options := api.options(opts...)
+	api.Close()
+	return options
+}
+
+func TestQueueConnectionRefused(t *testing.T) {
+	var queued []QueuedRequest
+	opts := closedApi(t, WithOfflineQueue(func(req QueuedRequest) error {
+		queued = append(queued, req)
+		return nil
+	}))
+
+	_, err := NewCredentialsService(testApiKey, testOrgId, opts...).DeleteIOS("0a1b2c3d-4e5f-6789-abcd-ef0123456789")
+	if err != ErrQueued {
+		t.Fatalf("got %v, want ErrQueued", err)
+	}
+
+	if len(queued) != 1 {
+		t.Fatalf("queued %d requests, want 1", len(queued))
+	}
+	if queued[0].Method != "DELETE" {
+		t.Errorf("Method = %s, want DELETE", queued[0].Method)
+	}
+	if auth := queued[0].Header.Get("Authorization"); auth != "" {
+		t.Errorf("the api key was queued: %q", auth)
+	}
+}
+
+func TestQueueSkipsUploads(t *testing.T) {
+	opts := closedApi(t, WithOfflineQueue(func(req QueuedRequest) error {
+		t.Errorf("queued %s %s", req.Method, req.URL)
+		return nil
+	}))
+
+	_, err := NewCredentialsService(testApiKey, testOrgId, opts...).RenameIOS("0a1b2c3d-4e5f-6789-abcd-ef0123456789", "release")
+	if err == nil || err == ErrQueued {
+		t.Fatalf("got %v, want the connection error", err)
+	}
+}
+
+func TestQueueSkipsTimeouts(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("DELETE /api/v1/orgs/example/credentials/signing/ios/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	})
+
+	opts := api.options(WithTimeout(50*time.Millisecond), WithOfflineQueue(func(req QueuedRequest) error {
+		t.Errorf("queued %s %s which may have reached the api", req.Method, req.URL)
+		return nil
+	}))
+
+	if _, err := NewCredentialsService(testApiKey, testOrgId, opts...).DeleteIOS("slow"); err == nil || err == ErrQueued {
+		t.Fatalf("got %v, want the timeout", err)
+	}
+}
+
+func TestReplayAuthorizes(t *testing.T) {
+	api := newFakeApi(t)
+	api.handle("DELETE /api/v1/orgs/example/credentials/signing/ios/queued", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	queued := QueuedRequest{
+		Method: "DELETE",
+		URL:    api.URL + "/api/v1/orgs/example/credentials/signing/ios/queued",
+		Header: http.Header{},
+		Queued: time.Now(),
+	}
+
+	if err := Replay(context.Background(), testApiKey, queued, api.options()...); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := api.requests[len(api.requests)-1].Header.Get("Authorization"), "Basic "+testApiKey; got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+}
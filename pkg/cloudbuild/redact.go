@@ -0,0 +1,20 @@
+package cloudbuild
+
+import "strings"
+
+// Redacted replaces secret values in logs and printed output
+const Redacted = "****"
+
+// secretFields are the request fields, headers and settings whose values are never printed
+var secretFields = map[string]bool{
+	"authorization":   true,
+	"apikey":          true,
+	"certificatepass": true,
+	"storepass":       true,
+	"keypass":         true,
+}
+
+// IsSecretField reports if values of the named field or header must not be printed, names are case insensitive
+func IsSecretField(name string) bool {
+	return secretFields[strings.ToLower(name)]
+}
@@ -0,0 +1,12 @@
+package responses
+
+import "time"
+
+type AuditEntry struct {
+	Created       time.Time              `json:"created"`
+	User          string                 `json:"user"`
+	Action        string                 `json:"action"`
+	ProjectId     string                 `json:"projectid,omitempty"`
+	BuildTargetId string                 `json:"buildtargetid,omitempty"`
+	Changes       map[string]interface{} `json:"changes,omitempty"`
+}
@@ -0,0 +1,83 @@
+package responses
+
+import "time"
+
+type BuildStatus string
+
+const (
+	BuildStatusQueued    BuildStatus = "queued"
+	BuildStatusSent      BuildStatus = "sentToBuilder"
+	BuildStatusStarted   BuildStatus = "started"
+	BuildStatusRestarted BuildStatus = "restarted"
+	BuildStatusSuccess   BuildStatus = "success"
+	BuildStatusFailure   BuildStatus = "failure"
+	BuildStatusCanceled  BuildStatus = "canceled"
+	BuildStatusUnknown   BuildStatus = "unknown"
+)
+
+// Finished reports if a build with this status will not change status again
+func (s BuildStatus) Finished() bool {
+	switch s {
+	case BuildStatusSuccess, BuildStatusFailure, BuildStatusCanceled, BuildStatusUnknown:
+		return true
+	default:
+		return false
+	}
+}
+
+type Build struct {
+	Number            int          `json:"build"`
+	BuildTargetId     string       `json:"buildtargetid"`
+	BuildTargetName   string       `json:"buildTargetName"`
+	Status            BuildStatus  `json:"buildStatus"`
+	Platform          Platform     `json:"platform"`
+	Created           time.Time    `json:"created"`
+	StartTime         time.Time    `json:"buildStartTime"`
+	Finished          time.Time    `json:"finished"`
+	TotalTimeSeconds  float64      `json:"totalTimeInSeconds"`
+	LastBuiltRevision string       `json:"lastBuiltRevision"`
+	Error             string       `json:"error,omitempty"`
+	ScmMeta           BuildScmMeta `json:"scmMeta"`
+	Links             BuildLinks   `json:"links"`
+}
+
+type BuildScmMeta struct {
+	Type     string `json:"type"`
+	Branch   string `json:"branch"`
+	CommitId string `json:"commitId"`
+	Message  string `json:"message"`
+	Author   string `json:"author"`
+}
+
+// Commit returns the hash of the commit the build was made from
+func (b *Build) Commit() string {
+	if b.ScmMeta.CommitId != "" {
+		return b.ScmMeta.CommitId
+	}
+	return b.LastBuiltRevision
+}
+
+type BuildLinks struct {
+	Self            *Link      `json:"self,omitempty"`
+	Log             *Link      `json:"log,omitempty"`
+	DownloadPrimary *Link      `json:"download_primary,omitempty"`
+	Artifacts       []Artifact `json:"artifacts,omitempty"`
+}
+
+type Artifact struct {
+	Key     string         `json:"key"`
+	Name    string         `json:"name"`
+	Primary bool           `json:"primary"`
+	Files   []ArtifactFile `json:"files"`
+}
+
+type ArtifactFile struct {
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	Href     string `json:"href"`
+}
+
+type ShareLink struct {
+	ShareId string    `json:"shareid"`
+	Expiry  time.Time `json:"shareExpiry"`
+}
@@ -0,0 +1,21 @@
+package responses
+
+type BuildTarget struct {
+	Name     string              `json:"name"`
+	Id       string              `json:"buildtargetid"`
+	Platform Platform            `json:"platform"`
+	Enabled  bool                `json:"enabled"`
+	Settings BuildTargetSettings `json:"settings"`
+	Links    map[string]Link     `json:"links"`
+}
+
+type BuildTargetSettings struct {
+	UnityVersion string         `json:"unityVersion"`
+	Scm          BuildTargetScm `json:"scm"`
+}
+
+type BuildTargetScm struct {
+	Type   string `json:"type"`
+	Repo   string `json:"repo"`
+	Branch string `json:"branch"`
+}
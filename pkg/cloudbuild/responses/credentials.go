@@ -15,6 +15,16 @@ type IOSCred struct {
 	// Links               map[string]json.RawMessage `json:"links"`
 }
 
+// Expiration returns when the credential stops working, the earlier of its certificate's and its provisioning
+// profile's expiration
+func (c IOSCred) Expiration() time.Time {
+	expiration := c.Certificate.Expiration
+	if profileExp := c.ProvisioningProfile.Expiration; !profileExp.IsZero() && (expiration.IsZero() || profileExp.Before(expiration)) {
+		expiration = profileExp
+	}
+	return expiration
+}
+
 type IOSCert struct {
 	TeamId         string    `json:"teamId"`
 	Name           string    `json:"certName"`
@@ -25,9 +35,24 @@ type IOSCert struct {
 
 type IOSProvisioningProfile struct {
 	TeamID              string    `json:"teamId"`
+	UUID                string    `json:"uuid,omitempty"`
 	BundleID            string    `json:"bundleId"`
 	Expiration          time.Time `json:"expiration"`
 	IsEnterpriseProfile bool      `json:"isEnterpriseProfile"`
 	Type                string    `json:"type"`
 	NumDevices          int       `json:"numDevices"`
 }
+
+type AndroidCred struct {
+	Platform Platform        `json:"platform"`
+	Label    string          `json:"label"`
+	Id       string          `json:"credentialid"`
+	Created  time.Time       `json:"created"`
+	LastMod  time.Time       `json:"lastMod"`
+	Keystore AndroidKeystore `json:"keystore"`
+}
+
+type AndroidKeystore struct {
+	Alias     string `json:"alias"`
+	DebugMode bool   `json:"debug"`
+}
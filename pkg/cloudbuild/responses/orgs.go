@@ -0,0 +1,7 @@
+package responses
+
+type User struct {
+	Name       string `json:"name"`
+	Email      string `json:"email"`
+	PrimaryOrg string `json:"primaryOrg"`
+}
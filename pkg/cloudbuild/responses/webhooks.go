@@ -0,0 +1,36 @@
+package responses
+
+type WebhookEvent string
+
+const (
+	WebhookBuildQueued    WebhookEvent = "ProjectBuildQueued"
+	WebhookBuildStarted   WebhookEvent = "ProjectBuildStarted"
+	WebhookBuildRestarted WebhookEvent = "ProjectBuildRestarted"
+	WebhookBuildSuccess   WebhookEvent = "ProjectBuildSuccess"
+	WebhookBuildFailure   WebhookEvent = "ProjectBuildFailure"
+	WebhookBuildCanceled  WebhookEvent = "ProjectBuildCanceled"
+)
+
+// WebhookEvents are every event a webhook can be sent for
+var WebhookEvents = []WebhookEvent{
+	WebhookBuildQueued,
+	WebhookBuildStarted,
+	WebhookBuildRestarted,
+	WebhookBuildSuccess,
+	WebhookBuildFailure,
+	WebhookBuildCanceled,
+}
+
+type Webhook struct {
+	Id       string         `json:"id"`
+	HookType string         `json:"hookType"`
+	Events   []WebhookEvent `json:"events"`
+	Config   WebhookConfig  `json:"config"`
+	Active   bool           `json:"active"`
+}
+
+type WebhookConfig struct {
+	Url       string `json:"url"`
+	Encoding  string `json:"encoding,omitempty"`
+	SslVerify bool   `json:"sslVerify"`
+}
@@ -0,0 +1,150 @@
+package cloudbuild
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var contentRangeRe = regexp.MustCompile(`^bytes (\d+)-\d+/(\d+|\*)$`)
+
+// errRangeIgnored is returned when a partial response doesn't start where the file ends, the download starts over
+var errRangeIgnored = errors.New("artifact server sent the wrong range")
+
+// interruptedError is a download cut off while reading the response body, what was written so far is kept
+type interruptedError struct {
+	err error
+}
+
+func (e *interruptedError) Error() string {
+	return fmt.Sprintf("artifact download interrupted: %v", e.err)
+}
+
+func (e *interruptedError) Unwrap() error {
+	return e.err
+}
+
+type interruptedReader struct {
+	r io.Reader
+}
+
+func (r interruptedReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	if err != nil && err != io.EOF {
+		err = &interruptedError{err}
+	}
+	return n, err
+}
+
+// DownloadArtifactFile downloads the artifact at artifactUrl to the file at path, returning its size. Bytes already in
+// the file are kept and only the rest is asked for with a Range header, so calling it again resumes an interrupted
+// download. A download cut off part way is resumed the same way, backing off between attempts like other requests
+func (c *BuildsService) DownloadArtifactFile(artifactUrl, path string, progress ProgressFunc) (int64, error) {
+	return c.DownloadArtifactFileContext(context.Background(), artifactUrl, path, progress)
+}
+
+func (c *BuildsService) DownloadArtifactFileContext(ctx context.Context, artifactUrl, path string, progress ProgressFunc) (int64, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	for attempt := 0; ; attempt++ {
+		offset, err := f.Seek(0, io.SeekEnd)
+		if err != nil {
+			return 0, err
+		}
+
+		size, err := c.downloadRange(ctx, artifactUrl, f, offset, progress)
+		if err == errRangeIgnored {
+			if err := f.Truncate(0); err != nil {
+				return 0, err
+			}
+		}
+		if _, interrupted := err.(*interruptedError); err == nil || attempt >= c.maxRetries || ctx.Err() != nil || (!interrupted && err != errRangeIgnored) {
+			return size, err
+		}
+
+		timer := time.NewTimer(retryBaseDelay << uint(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// downloadRange appends the artifact from offset onwards to f, a server ignoring the Range header has f written
+// from the start instead
+func (c *BuildsService) downloadRange(ctx context.Context, artifactUrl string, f *os.File, offset int64, progress ProgressFunc) (int64, error) {
+	// artifact links are pre-signed so no auth header is added
+	req, err := http.NewRequest("GET", artifactUrl, nil)
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	// artifacts are already compressed and byte ranges have to be of the file as stored
+	req.Header.Set("Accept-Encoding", "identity")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	total := int64(-1)
+
+	switch {
+	case resp.StatusCode == http.StatusPartialContent:
+		match := contentRangeRe.FindStringSubmatch(resp.Header.Get("Content-Range"))
+		if match == nil || match[1] != strconv.FormatInt(offset, 10) {
+			return 0, errRangeIgnored
+		}
+		if size, err := strconv.ParseInt(match[2], 10, 64); err == nil {
+			total = size
+		}
+
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// the file is already complete when it is as long as the artifact
+		if resp.Header.Get("Content-Range") == fmt.Sprintf("bytes */%d", offset) {
+			return offset, nil
+		}
+		return 0, errRangeIgnored
+
+	case resp.StatusCode >= 300:
+		return 0, fmt.Errorf("artifact download failed: %s", resp.Status)
+
+	default:
+		if offset > 0 {
+			if err := f.Truncate(0); err != nil {
+				return 0, err
+			}
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return 0, err
+			}
+			offset = 0
+		}
+		if resp.ContentLength >= 0 {
+			total = resp.ContentLength
+		}
+	}
+
+	var w io.Writer = f
+	if progress != nil {
+		w = &progressWriter{w: f, written: offset, total: total, progress: progress}
+	}
+
+	n, err := io.Copy(w, interruptedReader{resp.Body})
+	return offset + n, err
+}
@@ -0,0 +1,129 @@
+package cloudbuild
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+	retryBaseDelay    = 500 * time.Millisecond
+	retryMaxDelay     = 30 * time.Second
+)
+
+// retryTransport retries idempotent requests that fail with a connection error, a 429 or a 5xx response. A POST may
+// have been applied before it failed so sending it again could create a duplicate
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+	baseDelay  time.Duration
+}
+
+func newRetryTransport(next http.RoundTripper, maxRetries int) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	if maxRetries <= 0 {
+		return next
+	}
+
+	return &retryTransport{
+		next:       next,
+		maxRetries: maxRetries,
+		baseDelay:  retryBaseDelay,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+
+		if attempt >= t.maxRetries || !idempotent(req.Method) || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		// requests with an idempotency key are retried by their caller, which first checks if the last attempt landed
+		if req.Header.Get(IdempotencyHeader) != "" {
+			return resp, err
+		}
+
+		// bodies without GetBody can't be replayed, so hand back what we have
+		if req.Body != nil && req.GetBody == nil {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+			}
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	delay := t.baseDelay << uint(attempt)
+	if delay <= 0 || delay > retryMaxDelay {
+		return retryMaxDelay
+	}
+	return delay
+}
+
+// idempotent reports whether sending a request with method more than once has the same effect as sending it once
+func idempotent(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "PUT", "DELETE":
+		return true
+	}
+	return false
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// parseRetryAfter reads a Retry-After header in either its delay-seconds or http-date form
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
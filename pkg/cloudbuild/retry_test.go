@@ -0,0 +1,49 @@
+package cloudbuild
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// retryAttempts sends a request with method to an api that always answers status and returns how many attempts
+// were made
+func retryAttempts(t *testing.T, method string, status int) int {
+	attempts := 0
+
+	api := newFakeApi(t)
+	api.handle(method+" /flaky", func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(status)
+	})
+
+	client := &http.Client{Transport: &retryTransport{next: http.DefaultTransport, maxRetries: 2, baseDelay: time.Millisecond}}
+
+	req, err := http.NewRequest(method, api.URL+"/flaky", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	return attempts
+}
+
+func TestRetryIdempotent(t *testing.T) {
+	for _, method := range []string{"GET", "PUT", "DELETE"} {
+		if attempts := retryAttempts(t, method, http.StatusServiceUnavailable); attempts != 3 {
+			t.Errorf("%s made %d attempts, want 3", method, attempts)
+		}
+	}
+}
+
+func TestRetrySkipsPost(t *testing.T) {
+	if attempts := retryAttempts(t, "POST", http.StatusBadGateway); attempts != 1 {
+		t.Errorf("POST made %d attempts, want 1", attempts)
+	}
+}
@@ -0,0 +1,160 @@
+package cloudbuild
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"golang.org/x/crypto/pkcs12"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// der encoding of the pkcs7 signedData oid 1.2.840.113549.1.7.2
+var oidSignedData = []byte{0x06, 0x09, 0x2a, 0x86, 0x48, 0x86, 0xf7, 0x0d, 0x01, 0x07, 0x02}
+
+var (
+	errNotProfile = errors.New("not a provisioning profile, expected a signed .mobileprovision file")
+	errNotP12     = errors.New("not a certificate, expected a .p12 file")
+)
+
+// ErrIncorrectP12Password is returned when a p12 can't be decrypted with the given password
+var ErrIncorrectP12Password = errors.New("incorrect certificate password")
+
+// ProvisioningProfile holds the details read from a .mobileprovision file
+type ProvisioningProfile struct {
+	Name           string
+	UUID           string
+	CreationDate   time.Time
+	ExpirationDate time.Time
+}
+
+// ReadProvisioningProfile reads the profile at path, checking it is wrapped in a pkcs7 envelope
+func ReadProvisioningProfile(path string) (*ProvisioningProfile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isSignedData(data) {
+		return nil, errNotProfile
+	}
+
+	plist := profilePlist(data)
+	if plist == nil {
+		return nil, errNotProfile
+	}
+
+	return &ProvisioningProfile{
+		Name:           plistString(plist, "Name"),
+		UUID:           plistString(plist, "UUID"),
+		CreationDate:   plistDate(plist, "CreationDate"),
+		ExpirationDate: plistDate(plist, "ExpirationDate"),
+	}, nil
+}
+
+// ReadP12Certificate decrypts the .p12 at path with password and returns its signing certificate
+func ReadP12Certificate(path, password string) (*x509.Certificate, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err == pkcs12.ErrIncorrectPassword {
+		return nil, ErrIncorrectP12Password
+	} else if err != nil {
+		return nil, err
+	}
+
+	var leaf *x509.Certificate
+	for _, block := range blocks {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if cert.IsCA {
+			continue
+		}
+
+		if leaf == nil || cert.NotAfter.Before(leaf.NotAfter) {
+			leaf = cert
+		}
+	}
+
+	if leaf == nil {
+		return nil, errors.New("no signing certificate found in p12")
+	}
+	return leaf, nil
+}
+
+// CheckP12 checks that path looks like a pkcs12 certificate bundle
+func CheckP12(path string) error {
+	if !strings.EqualFold(filepath.Ext(path), ".p12") {
+		return errNotP12
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// a pfx is a der sequence
+	if len(data) == 0 || data[0] != 0x30 {
+		return errNotP12
+	}
+	return nil
+}
+
+// isSignedData checks for a pkcs7 content info header with a signedData content type,
+// profiles are often ber encoded with indefinite lengths so only the header is inspected
+func isSignedData(data []byte) bool {
+	if len(data) < 2 || data[0] != 0x30 {
+		return false
+	}
+
+	offset := 2
+	if l := data[1]; l > 0x80 {
+		offset += int(l & 0x7f)
+	}
+
+	if len(data) < offset+len(oidSignedData) {
+		return false
+	}
+	return bytes.Equal(data[offset:offset+len(oidSignedData)], oidSignedData)
+}
+
+// profilePlist returns the xml plist embedded in a provisioning profile
+func profilePlist(data []byte) []byte {
+	start := bytes.Index(data, []byte("<?xml"))
+	end := bytes.Index(data, []byte("</plist>"))
+	if start < 0 || end < start {
+		return nil
+	}
+	return data[start : end+len("</plist>")]
+}
+
+func plistString(plist []byte, key string) string {
+	re := regexp.MustCompile(`<key>` + regexp.QuoteMeta(key) + `</key>\s*<string>([^<]*)</string>`)
+	if match := re.FindSubmatch(plist); match != nil {
+		return string(match[1])
+	}
+	return ""
+}
+
+func plistDate(plist []byte, key string) time.Time {
+	re := regexp.MustCompile(`<key>` + regexp.QuoteMeta(key) + `</key>\s*<date>([^<]*)</date>`)
+	if match := re.FindSubmatch(plist); match != nil {
+		if date, err := time.Parse(time.RFC3339, string(match[1])); err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,64 @@
+package cloudbuild
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultTimeout is how long a request may take to get a response when no WithTimeout option is given
+const DefaultTimeout = 30 * time.Second
+
+// timeoutTransport fails requests that don't get a response within timeout, reading the response body is not
+// limited so large downloads are unaffected
+type timeoutTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+func newTimeoutTransport(next http.RoundTripper, timeout time.Duration) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	if timeout <= 0 {
+		return next
+	}
+
+	return &timeoutTransport{next, timeout}
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := time.AfterFunc(t.timeout, cancel)
+
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if !timer.Stop() && req.Context().Err() == nil {
+		cancel()
+		if err == nil {
+			resp.Body.Close()
+		}
+		return nil, fmt.Errorf("%s %s: no response after %s", req.Method, req.URL, t.timeout)
+	}
+
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = &cancelOnClose{resp.Body, cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,93 @@
+package cloudbuild
+
+import (
+	"context"
+	"fmt"
+	"github.com/cmcpasserby/ucb/pkg/cloudbuild/responses"
+	"net/http"
+)
+
+type WebhooksService struct {
+	*client
+}
+
+func NewWebhooksService(apiKey, orgId string, opts ...Option) *WebhooksService {
+	return &WebhooksService{
+		client: newClient(apiKey, orgId, opts...),
+	}
+}
+
+func (c *WebhooksService) List(projectId string) ([]responses.Webhook, error) {
+	return c.ListContext(context.Background(), projectId)
+}
+
+func (c *WebhooksService) ListContext(ctx context.Context, projectId string) ([]responses.Webhook, error) {
+	if projectId == "" {
+		return nil, errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/hooks", c.OrgId, projectId)
+
+	var hooks []responses.Webhook
+	if err := c.getAll(ctx, path, nil, &hooks); err != nil {
+		return nil, err
+	}
+
+	return hooks, nil
+}
+
+// Create adds a web hook that posts json to url for each of events
+func (c *WebhooksService) Create(projectId, url string, events []responses.WebhookEvent) (*responses.Webhook, error) {
+	return c.CreateContext(context.Background(), projectId, url, events)
+}
+
+func (c *WebhooksService) CreateContext(ctx context.Context, projectId, url string, events []responses.WebhookEvent) (*responses.Webhook, error) {
+	if projectId == "" {
+		return nil, errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/hooks", c.OrgId, projectId)
+
+	body := responses.Webhook{
+		HookType: "web",
+		Events:   events,
+		Config:   responses.WebhookConfig{Url: url, Encoding: "json", SslVerify: true},
+		Active:   true,
+	}
+
+	req, err := c.newRequest(ctx, "POST", path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var hook responses.Webhook
+	if _, err := c.do(req, &hook); err != nil {
+		return nil, err
+	}
+
+	return &hook, nil
+}
+
+func (c *WebhooksService) Delete(projectId, hookId string) (*http.Response, error) {
+	return c.DeleteContext(context.Background(), projectId, hookId)
+}
+
+func (c *WebhooksService) DeleteContext(ctx context.Context, projectId, hookId string) (*http.Response, error) {
+	if projectId == "" {
+		return nil, errNoProjectId
+	}
+
+	path := fmt.Sprintf("api/v1/orgs/%s/projects/%s/hooks/%s", c.OrgId, projectId, hookId)
+
+	req, err := c.newRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}